@@ -0,0 +1,64 @@
+package reference
+
+import "strings"
+
+// Warning describes a correction applied to a non-canonical reference that
+// was accepted by [ParseAudit].
+type Warning string
+
+// Warnings reported by [ParseAudit].
+const (
+	// WarningDefaultDomain is reported when the default domain ("docker.io")
+	// is used because the reference has no domain.
+	WarningDefaultDomain Warning = EventDefaultDomain
+
+	// WarningLegacyDomain is reported when the legacy domain of Docker Hub
+	// ("index.docker.io") is replaced with "docker.io".
+	WarningLegacyDomain Warning = EventLegacyDomain
+
+	// WarningLibraryPrefix is reported when the "library/" prefix for official
+	// images is added.
+	WarningLibraryPrefix Warning = EventLibraryPrefix
+
+	// WarningDefaultTag is reported when the default tag ("latest") is added
+	// because the reference has no tag or digest.
+	WarningDefaultTag Warning = EventDefaultTag
+
+	// WarningTagDropped is reported when the tag is removed because the
+	// reference also has a digest, which takes precedence.
+	WarningTagDropped Warning = "tag-dropped"
+
+	// WarningUppercaseDomain is reported when the domain contains uppercase
+	// characters. The domain is preserved as-is, but such a reference is
+	// ambiguous, as the first component is only treated as a domain because
+	// uppercase characters are not allowed in the remote-name.
+	WarningUppercaseDomain Warning = "uppercase-domain"
+)
+
+// ParseAudit parses s in the same way as [ParseDockerRef], and additionally
+// returns a [Warning] for each correction that was applied to make s a
+// canonical reference, in the order they were applied. No warnings are
+// returned if s was already canonical.
+func ParseAudit(s string) (Named, []Warning, error) {
+	named, err := parseNormalized(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	var warnings []Warning
+	for _, event := range normalizationEvents(s, isDomainComponent) {
+		warnings = append(warnings, Warning(event))
+	}
+	if domain := Domain(named); strings.ToLower(domain) != domain {
+		warnings = append(warnings, WarningUppercaseDomain)
+	}
+	if IsNameOnly(named) {
+		warnings = append(warnings, WarningDefaultTag)
+	} else if _, ok := named.(namedTaggedDigested); ok {
+		warnings = append(warnings, WarningTagDropped)
+	}
+	ref, err := dockerRef(named)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ref, warnings, nil
+}
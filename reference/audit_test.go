@@ -0,0 +1,91 @@
+package reference
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAudit(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		expected string
+		warnings []Warning
+	}{
+		{
+			input:    "docker.io/library/nginx:1.25",
+			expected: "docker.io/library/nginx:1.25",
+		},
+		{
+			input:    "example.com/app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			expected: "example.com/app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		},
+		{
+			input:    "nginx",
+			expected: "docker.io/library/nginx:latest",
+			warnings: []Warning{WarningDefaultDomain, WarningLibraryPrefix, WarningDefaultTag},
+		},
+		{
+			input:    "user/app:1.0",
+			expected: "docker.io/user/app:1.0",
+			warnings: []Warning{WarningDefaultDomain},
+		},
+		{
+			input:    "index.docker.io/nginx:1.25",
+			expected: "docker.io/library/nginx:1.25",
+			warnings: []Warning{WarningLegacyDomain, WarningLibraryPrefix},
+		},
+		{
+			input:    "example.com/app",
+			expected: "example.com/app:latest",
+			warnings: []Warning{WarningDefaultTag},
+		},
+		{
+			input:    "example.com/app:1.0@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			expected: "example.com/app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			warnings: []Warning{WarningTagDropped},
+		},
+		{
+			input:    "Foo/bar:1.0",
+			expected: "Foo/bar:1.0",
+			warnings: []Warning{WarningUppercaseDomain},
+		},
+		{
+			input:    "Example.com/app",
+			expected: "Example.com/app:latest",
+			warnings: []Warning{WarningUppercaseDomain, WarningDefaultTag},
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			ref, warnings, err := ParseAudit(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ref.String() != testcase.expected {
+				t.Errorf("unexpected reference: got %s, expected %s", ref, testcase.expected)
+			}
+			if !reflect.DeepEqual(warnings, testcase.warnings) {
+				t.Errorf("unexpected warnings: got %q, expected %q", warnings, testcase.warnings)
+			}
+			expected, err := ParseDockerRef(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ref.String() != expected.String() {
+				t.Errorf("result differs from ParseDockerRef: got %s, expected %s", ref, expected)
+			}
+		})
+	}
+}
+
+func TestParseAuditInvalid(t *testing.T) {
+	t.Parallel()
+	for _, input := range []string{"", "example.com/App", "docker.io/library/nginx:"} {
+		if ref, warnings, err := ParseAudit(input); err == nil {
+			t.Errorf("%q: expected error, got %v %q", input, ref, warnings)
+		}
+	}
+}
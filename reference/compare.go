@@ -0,0 +1,233 @@
+package reference
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// components holds the individual components of a reference. Components that
+// are not present in the reference are empty.
+type components struct {
+	domain string
+	path   string
+	tag    string
+	digest digest.Digest
+}
+
+// componentsOf returns the components of ref.
+func componentsOf(ref Reference) components {
+	var c components
+	if named, ok := ref.(Named); ok {
+		c.domain, c.path = Domain(named), Path(named)
+	}
+	if tagged, ok := ref.(Tagged); ok {
+		c.tag = tagged.Tag()
+	}
+	if digested, ok := ref.(Digested); ok {
+		c.digest = digested.Digest()
+	}
+	return c
+}
+
+// canonicalComponentsOf returns the components of ref, with the repository
+// canonicalized by canonicalRepository.
+func canonicalComponentsOf(ref Reference) components {
+	c := componentsOf(ref)
+	if _, ok := ref.(Named); ok {
+		c.domain, c.path = canonicalRepository(c.domain, c.path)
+	}
+	return c
+}
+
+// canonicalRepository returns the canonical domain and path of a repository,
+// folding all spellings of a Docker Hub repository into one. A first
+// component that is not a domain according to Docker's rules (see
+// isDomainComponent) is moved back into the path, as [Parse] treats any
+// first component as a domain. The domain is translated by [CanonicalDomain],
+// a missing domain is replaced by "docker.io", and the "library/" prefix is
+// added to official images.
+func canonicalRepository(domain, path string) (string, string) {
+	if domain != "" && !isDomainComponent(domain) {
+		domain, path = "", domain+"/"+path
+	}
+	domain = CanonicalDomain(domain)
+	if domain == "" {
+		domain = defaultDomain
+	}
+	if domain == defaultDomain && !strings.ContainsRune(path, '/') {
+		path = officialRepoPrefix + path
+	}
+	return domain, path
+}
+
+// RepositoryKey returns a string identifying the repository of ref, for use
+// as a map key. All spellings of a Docker Hub repository share the same key:
+// for example, "redis", "docker.io/redis", and "index.docker.io/library/redis"
+// all have the key "docker.io/library/redis". The tag and digest of ref are
+// ignored.
+func RepositoryKey(ref Named) string {
+	domain, path := canonicalRepository(Domain(ref), Path(ref))
+	return domain + "/" + path
+}
+
+// CacheKey returns a string identifying ref, for use as a cache key. Like
+// [RepositoryKey], all spellings of a Docker Hub repository share the same
+// key, and the tag and digest are appended as in the string representation
+// of a reference. Two references have the same key if, and only if, they are
+// equal according to [Equal].
+func CacheKey(ref Reference) string {
+	c := canonicalComponentsOf(ref)
+	var key string
+	if c.path != "" {
+		key = c.domain + "/" + c.path
+	}
+	if c.tag != "" {
+		key += ":" + c.tag
+	}
+	if c.digest != "" {
+		if key != "" {
+			key += "@"
+		}
+		key += c.digest.String()
+	}
+	return key
+}
+
+// Equal reports whether a and b have the same domain, path, tag, and digest.
+// Docker Hub repositories are compared in their canonical form, so that
+// "redis", "docker.io/library/redis", and "index.docker.io/redis" are equal.
+func Equal(a, b Reference) bool {
+	return canonicalComponentsOf(a) == canonicalComponentsOf(b)
+}
+
+// EqualDefaultingTag reports whether a and b are equal according to [Equal],
+// treating references without a tag as tagged with the default tag
+// ("latest"), as done by [TagNameOnly]. For example, "nginx" is equal to
+// "nginx:latest".
+//
+// The default tag is only applied if neither reference has a digest, as a
+// digest identifies the content regardless of the tag; references with a
+// digest are compared as-is, so "nginx@<digest>" is not equal to
+// "nginx:latest@<digest>".
+func EqualDefaultingTag(a, b Named) bool {
+	_, aDigested := a.(Digested)
+	_, bDigested := b.(Digested)
+	if !aDigested && !bDigested {
+		a, b = TagNameOnly(a), TagNameOnly(b)
+	}
+	return Equal(a, b)
+}
+
+// Compare returns an integer comparing a and b. The result is 0 if a and b
+// are equal according to [Equal], -1 if a sorts before b, and +1 otherwise.
+// References are ordered by domain, then by path, tag, and digest, using the
+// canonical form of Docker Hub repositories, as for [Equal].
+//
+// Components are compared byte-wise, which does not depend on the locale or
+// platform. Domains are restricted to ASCII by the reference grammar, so
+// internationalized domain names are compared in their punycode ("xn--")
+// form.
+func Compare(a, b Reference) int {
+	ca, cb := canonicalComponentsOf(a), canonicalComponentsOf(b)
+	if c := strings.Compare(ca.domain, cb.domain); c != 0 {
+		return c
+	}
+	if c := strings.Compare(ca.path, cb.path); c != 0 {
+		return c
+	}
+	if c := strings.Compare(ca.tag, cb.tag); c != 0 {
+		return c
+	}
+	return strings.Compare(ca.digest.String(), cb.digest.String())
+}
+
+// SameTag reports whether a and b refer to the same tag in the same
+// repository, ignoring their digests (if any). Unlike [Equal], references
+// pointing to different digests are considered the same if their names and
+// tags match. False is returned if either reference has no tag.
+func SameTag(a, b Named) bool {
+	ca, cb := canonicalComponentsOf(a), canonicalComponentsOf(b)
+	if ca.tag == "" || cb.tag == "" {
+		return false
+	}
+	return ca.domain == cb.domain && ca.path == cb.path && ca.tag == cb.tag
+}
+
+// SameExceptDomain reports whether a and b have the same path, tag, and
+// digest, but different domains, for example, to verify that rewriting a
+// reference to a mirror did not alter anything but the domain. Domains are
+// compared using [CanonicalDomain], so all aliases of Docker Hub are the same
+// domain. Paths are compared as-is, as mirrors of Docker Hub also use the
+// "library/" prefix for official images. False is returned if a and b have
+// the same domain.
+func SameExceptDomain(a, b Named) bool {
+	ca, cb := componentsOf(a), componentsOf(b)
+	return CanonicalDomain(ca.domain) != CanonicalDomain(cb.domain) &&
+		ca.path == cb.path && ca.tag == cb.tag && ca.digest == cb.digest
+}
+
+// MoreSpecific returns whichever of a and b carries more information about
+// the same repository: a reference with a digest is more specific than one
+// with a tag only, which is more specific than a name only, and a reference
+// with both a tag and a digest is the most specific. If both are equally
+// specific, a is returned.
+//
+// An error is returned if a and b refer to different repositories (see
+// [RepositoryKey]), or if they conflict, that is, if both have a tag or both
+// have a digest, and these differ.
+func MoreSpecific(a, b Named) (Named, error) {
+	if RepositoryKey(a) != RepositoryKey(b) {
+		return nil, fmt.Errorf("references must refer to the same repository: %q and %q", a.Name(), b.Name())
+	}
+	ca, cb := componentsOf(a), componentsOf(b)
+	if ca.digest != "" && cb.digest != "" && ca.digest != cb.digest {
+		return nil, fmt.Errorf("conflicting digests: %q and %q", ca.digest, cb.digest)
+	}
+	if ca.tag != "" && cb.tag != "" && ca.tag != cb.tag {
+		return nil, fmt.Errorf("conflicting tags: %q and %q", ca.tag, cb.tag)
+	}
+	if specificity(cb) > specificity(ca) {
+		return b, nil
+	}
+	return a, nil
+}
+
+// specificity returns a number ordering c by the information it carries,
+// as described by [MoreSpecific].
+func specificity(c components) int {
+	var n int
+	if c.tag != "" {
+		n++
+	}
+	if c.digest != "" {
+		n += 2
+	}
+	return n
+}
+
+// Diff returns a human-readable description of the components that differ
+// between a and b, for example:
+//
+//	domain: "docker.io" != "gcr.io"; tag: "1.0" != "2.0"
+//
+// An empty string is returned if a and b are equal according to [Equal].
+func Diff(a, b Reference) string {
+	ca, cb := canonicalComponentsOf(a), canonicalComponentsOf(b)
+	var diffs []string
+	for _, d := range []struct {
+		component string
+		a, b      string
+	}{
+		{component: "domain", a: ca.domain, b: cb.domain},
+		{component: "path", a: ca.path, b: cb.path},
+		{component: "tag", a: ca.tag, b: cb.tag},
+		{component: "digest", a: ca.digest.String(), b: cb.digest.String()},
+	} {
+		if d.a != d.b {
+			diffs = append(diffs, fmt.Sprintf("%s: %q != %q", d.component, d.a, d.b))
+		}
+	}
+	return strings.Join(diffs, "; ")
+}
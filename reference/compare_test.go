@@ -0,0 +1,471 @@
+package reference
+
+import (
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		a, b     string
+		expected bool
+	}{
+		{a: "nginx", b: "docker.io/library/nginx", expected: true},
+		{a: "nginx:latest", b: "docker.io/library/nginx:latest", expected: true},
+		{a: "nginx", b: "nginx:latest", expected: false},
+		{a: "nginx:1.25", b: "nginx:1.26", expected: false},
+		{a: "example.com/foo", b: "example.org/foo", expected: false},
+		{
+			a:        "nginx@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+			b:        "nginx@sha256:e6693c20186f837fc393390135d8a598a96a833917917789d63766cab6c59582",
+			expected: false,
+		},
+		{
+			a:        "sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+			b:        "86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+			expected: true,
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.a+"="+testcase.b, func(t *testing.T) {
+			t.Parallel()
+			a, err := ParseAnyReference(testcase.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ParseAnyReference(testcase.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := Equal(a, b); actual != testcase.expected {
+				t.Errorf("expected %v, got %v", testcase.expected, actual)
+			}
+			if actual := Equal(b, a); actual != testcase.expected {
+				t.Errorf("expected %v for swapped arguments, got %v", testcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		a, b     string
+		expected string
+	}{
+		{
+			a:        "nginx:1.25",
+			b:        "docker.io/library/nginx:1.25",
+			expected: "",
+		},
+		{
+			a:        "nginx:1.25",
+			b:        "nginx:1.26",
+			expected: `tag: "1.25" != "1.26"`,
+		},
+		{
+			a:        "nginx",
+			b:        "gcr.io/library/nginx:latest",
+			expected: `domain: "docker.io" != "gcr.io"; tag: "" != "latest"`,
+		},
+		{
+			a:        "example.com/team/app",
+			b:        "example.com/team/api",
+			expected: `path: "team/app" != "team/api"`,
+		},
+		{
+			a:        "nginx:1.25@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+			b:        "nginx:1.25",
+			expected: `digest: "sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa" != ""`,
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.a+"="+testcase.b, func(t *testing.T) {
+			t.Parallel()
+			a, err := ParseNormalizedNamed(testcase.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ParseNormalizedNamed(testcase.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := Diff(a, b); actual != testcase.expected {
+				t.Errorf("unexpected diff: got %q, expected %q", actual, testcase.expected)
+			}
+			if (Diff(a, b) == "") != Equal(a, b) {
+				t.Errorf("Diff and Equal disagree for %q and %q", a, b)
+			}
+		})
+	}
+}
+
+func TestSameTag(t *testing.T) {
+	t.Parallel()
+	const (
+		dgst1 = "@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+		dgst2 = "@sha256:e6693c20186f837fc393390135d8a598a96a833917917789d63766cab6c59582"
+	)
+	testcases := []struct {
+		a, b     string
+		expected bool
+	}{
+		// matching tag
+		{a: "nginx:1.25", b: "docker.io/library/nginx:1.25", expected: true},
+		{a: "nginx:1.25" + dgst1, b: "nginx:1.25", expected: true},
+		{a: "nginx:1.25" + dgst1, b: "nginx:1.25" + dgst1, expected: true},
+		{a: "nginx:1.25" + dgst1, b: "nginx:1.25" + dgst2, expected: true},
+		// mismatching tag
+		{a: "nginx:1.25", b: "nginx:1.26", expected: false},
+		{a: "nginx:1.25" + dgst1, b: "nginx:1.26" + dgst1, expected: false},
+		{a: "nginx:1.25", b: "gcr.io/library/nginx:1.25", expected: false},
+		// missing tag
+		{a: "nginx", b: "nginx", expected: false},
+		{a: "nginx" + dgst1, b: "nginx:1.25" + dgst1, expected: false},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.a+"="+testcase.b, func(t *testing.T) {
+			t.Parallel()
+			a, err := ParseNormalizedNamed(testcase.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ParseNormalizedNamed(testcase.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := SameTag(a, b); actual != testcase.expected {
+				t.Errorf("expected %v, got %v", testcase.expected, actual)
+			}
+			if actual := SameTag(b, a); actual != testcase.expected {
+				t.Errorf("expected %v for swapped arguments, got %v", testcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSameExceptDomain(t *testing.T) {
+	t.Parallel()
+	const dgst = "@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+	testcases := []struct {
+		a, b     string
+		expected bool
+	}{
+		// mirror
+		{a: "nginx:1.25", b: "mirror.example.com/library/nginx:1.25", expected: true},
+		{a: "nginx:1.25" + dgst, b: "mirror.example.com/library/nginx:1.25" + dgst, expected: true},
+		{a: "example.com/app", b: "example.com:5000/app", expected: true},
+		// same domain
+		{a: "nginx:1.25", b: "nginx:1.25", expected: false},
+		{a: "nginx:1.25", b: "index.docker.io/library/nginx:1.25", expected: false},
+		// other differences
+		{a: "nginx:1.25", b: "mirror.example.com/library/nginx:1.26", expected: false},
+		{a: "nginx:1.25", b: "mirror.example.com/library/nginx:1.25" + dgst, expected: false},
+		{a: "nginx:1.25", b: "mirror.example.com/nginx:1.25", expected: false},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.a+"="+testcase.b, func(t *testing.T) {
+			t.Parallel()
+			a, b := mustParseNormalized(t, testcase.a), mustParseNormalized(t, testcase.b)
+			if actual := SameExceptDomain(a, b); actual != testcase.expected {
+				t.Errorf("expected %v, got %v", testcase.expected, actual)
+			}
+			if actual := SameExceptDomain(b, a); actual != testcase.expected {
+				t.Errorf("expected %v for swapped arguments, got %v", testcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMoreSpecific(t *testing.T) {
+	t.Parallel()
+	const (
+		dgst1 = "@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+		dgst2 = "@sha256:e6693c20186f837fc393390135d8a598a96a833917917789d63766cab6c59582"
+	)
+	testcases := []struct {
+		a, b     string
+		expected string
+		err      bool
+	}{
+		{a: "nginx", b: "docker.io/library/nginx:1.25", expected: "docker.io/library/nginx:1.25"},
+		{a: "nginx:1.25", b: "nginx" + dgst1, expected: "docker.io/library/nginx" + dgst1},
+		{a: "nginx:1.25" + dgst1, b: "nginx" + dgst1, expected: "docker.io/library/nginx:1.25" + dgst1},
+		{a: "nginx:1.25", b: "nginx:1.25" + dgst1, expected: "docker.io/library/nginx:1.25" + dgst1},
+		{a: "nginx:1.25", b: "index.docker.io/library/nginx:1.25", expected: "docker.io/library/nginx:1.25"},
+		{a: "nginx" + dgst1, b: "nginx" + dgst2, err: true},
+		{a: "nginx:1.25", b: "nginx:1.26", err: true},
+		{a: "nginx:1.25", b: "example.com/nginx" + dgst1, err: true},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.a+"="+testcase.b, func(t *testing.T) {
+			t.Parallel()
+			a, b := mustParseNormalized(t, testcase.a), mustParseNormalized(t, testcase.b)
+			for _, args := range [][2]Named{{a, b}, {b, a}} {
+				named, err := MoreSpecific(args[0], args[1])
+				if testcase.err {
+					if err == nil {
+						t.Errorf("MoreSpecific(%q, %q): expected error, got %q", args[0], args[1], named)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if named.String() != testcase.expected {
+					t.Errorf("MoreSpecific(%q, %q): got %q, expected %q", args[0], args[1], named, testcase.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+	// sorted is in the expected order. The punycode domains lock the
+	// byte-wise ordering: "xn--" sorts after "www" and before "z", and
+	// uppercase sorts before lowercase.
+	sorted := []string{
+		"Foo/bar",
+		"docker.io/library/nginx",
+		"docker.io/library/nginx:1.25",
+		"docker.io/library/nginx:1.25@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+		"docker.io/library/nginx:1.25@sha256:e6693c20186f837fc393390135d8a598a96a833917917789d63766cab6c59582",
+		"docker.io/library/nginx:latest",
+		"www.example.com/app",
+		"xn--bcher-kva.example/app",
+		"xn--n3h.com/app",
+		"xn--n3h.com:18080/app",
+		"z.example.com/app",
+	}
+	refs := make([]Named, len(sorted))
+	for i, s := range sorted {
+		ref, err := ParseNormalizedNamed(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs[i] = ref
+	}
+	for i := range refs {
+		for j := range refs {
+			expected := 0
+			if i < j {
+				expected = -1
+			} else if i > j {
+				expected = 1
+			}
+			if actual := Compare(refs[i], refs[j]); actual != expected {
+				t.Errorf("Compare(%q, %q): expected %d, got %d", refs[i], refs[j], expected, actual)
+			}
+		}
+	}
+
+	// Sort must agree with the byte-wise ordering for references of the
+	// same precedence.
+	shuffled := []string{sorted[8], sorted[10], sorted[6], sorted[7], sorted[9]}
+	expected := []string{sorted[6], sorted[7], sorted[8], sorted[9], sorted[10]}
+	for i, s := range Sort(shuffled) {
+		if s != expected[i] {
+			t.Errorf("Sort: wrong value at %d, got %q, expected %q", i, s, expected[i])
+		}
+	}
+}
+
+func TestCompareTotalOrder(t *testing.T) {
+	t.Parallel()
+	const (
+		dgst1 = "sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+		dgst2 = "sha256:e6693c20186f837fc393390135d8a598a96a833917917789d63766cab6c59582"
+	)
+	// References are parsed with Parse, without normalization, so that the
+	// different spellings of Docker Hub repositories are included.
+	inputs := []string{
+		"nginx",
+		"nginx:1.25",
+		"library/nginx",
+		"docker.io/nginx",
+		"docker.io/library/nginx",
+		"docker.io/library/nginx:1.25",
+		"index.docker.io/library/nginx:1.25",
+		"registry-1.docker.io/nginx:1.25",
+		"docker.io/library/nginx@" + dgst1,
+		"docker.io/library/nginx:1.25@" + dgst1,
+		"docker.io/library/nginx:1.25@" + dgst2,
+		"user/app",
+		"docker.io/user/app",
+		"example.com/app",
+		"example.com:5000/app",
+		"example.com:5000/app:1.0",
+		"localhost/app",
+		"localhost:5000/app",
+		"[::1]/app",
+		"[::1]:5000/app",
+		"[2001:db8::1]:5000/app:1.0",
+		"127.0.0.1:5000/app",
+		"Foo/bar",
+		"xn--n3h.com/app",
+	}
+	var refs []Reference
+	for _, input := range inputs {
+		ref, err := Parse(input)
+		if err != nil {
+			t.Fatalf("%q: %v", input, err)
+		}
+		refs = append(refs, ref)
+	}
+	for _, dgst := range []string{dgst1, dgst2} {
+		ref, err := ParseAnyReference(dgst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs = append(refs, ref)
+	}
+
+	for _, a := range refs {
+		if c := Compare(a, a); c != 0 {
+			t.Errorf("Compare(%q, %q) = %d, expected 0", a, a, c)
+		}
+		for _, b := range refs {
+			ab, ba := Compare(a, b), Compare(b, a)
+			if ab != -ba {
+				t.Errorf("not antisymmetric: Compare(%q, %q) = %d, Compare(%q, %q) = %d", a, b, ab, b, a, ba)
+			}
+			if equal := Equal(a, b); equal != (ab == 0) {
+				t.Errorf("inconsistent with Equal: Compare(%q, %q) = %d, Equal = %v", a, b, ab, equal)
+			}
+			if sameKey := CacheKey(a) == CacheKey(b); sameKey != (ab == 0) {
+				t.Errorf("inconsistent with CacheKey: Compare(%q, %q) = %d, CacheKey %q and %q", a, b, ab, CacheKey(a), CacheKey(b))
+			}
+			for _, c := range refs {
+				if ab <= 0 && Compare(b, c) <= 0 && Compare(a, c) > 0 {
+					t.Errorf("not transitive: %q <= %q <= %q, but Compare(%q, %q) > 0", a, b, c, a, c)
+				}
+			}
+		}
+	}
+}
+
+func TestEqualDefaultingTag(t *testing.T) {
+	t.Parallel()
+	const dgst = "@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+	testcases := []struct {
+		a, b     string
+		expected bool
+	}{
+		{a: "nginx", b: "nginx:latest", expected: true},
+		{a: "nginx", b: "docker.io/library/nginx:latest", expected: true},
+		{a: "nginx", b: "nginx", expected: true},
+		{a: "nginx", b: "nginx:1.25", expected: false},
+		{a: "nginx:1.25", b: "nginx:latest", expected: false},
+		{a: "nginx" + dgst, b: "nginx" + dgst, expected: true},
+		{a: "nginx" + dgst, b: "nginx:latest" + dgst, expected: false},
+		{a: "nginx" + dgst, b: "nginx:latest", expected: false},
+		{a: "nginx", b: "nginx:latest" + dgst, expected: false},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.a+"="+testcase.b, func(t *testing.T) {
+			t.Parallel()
+			a, err := ParseNormalizedNamed(testcase.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ParseNormalizedNamed(testcase.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := EqualDefaultingTag(a, b); actual != testcase.expected {
+				t.Errorf("expected %v, got %v", testcase.expected, actual)
+			}
+			if actual := EqualDefaultingTag(b, a); actual != testcase.expected {
+				t.Errorf("expected %v for swapped arguments, got %v", testcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRepositoryKey(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "redis", expected: "docker.io/library/redis"},
+		{input: "docker.io/redis", expected: "docker.io/library/redis"},
+		{input: "docker.io/library/redis:7", expected: "docker.io/library/redis"},
+		{input: "index.docker.io/library/redis", expected: "docker.io/library/redis"},
+		{input: "registry-1.docker.io/redis", expected: "docker.io/library/redis"},
+		{input: "index.docker.io/user/app", expected: "docker.io/user/app"},
+		{input: "user/app", expected: "docker.io/user/app"},
+		{input: "library/foo/bar", expected: "docker.io/library/foo/bar"},
+		{input: "Foo/bar", expected: "Foo/bar"},
+		{input: "example.com/app:1.0", expected: "example.com/app"},
+		{input: "example.com/app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", expected: "example.com/app"},
+		{input: "localhost:5000/app", expected: "localhost:5000/app"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			ref, err := Parse(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key := RepositoryKey(ref.(Named)); key != testcase.expected {
+				t.Errorf("unexpected key: got %q, expected %q", key, testcase.expected)
+			}
+		})
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	t.Parallel()
+	keys := map[string]int{}
+	for i, tc := range repositoryInfoTestcases {
+		for _, r := range tc.refStrings() {
+			normalized, err := ParseNormalizedNamed(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := Parse(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, ref := range []Reference{normalized, parsed, normalized.(normalizedNamed).Familiar()} {
+				key := CacheKey(ref)
+				if key != tc.FullName {
+					t.Errorf("%q: unexpected key: got %q, expected %q", r, key, tc.FullName)
+				}
+				if j, ok := keys[key]; ok && j != i {
+					t.Errorf("%q: key %q is shared with %q", r, key, repositoryInfoTestcases[j].FullName)
+				}
+				keys[key] = i
+				if !Equal(ref, normalized) || Compare(ref, normalized) != 0 {
+					t.Errorf("%q: expected %q to be equal to %q", r, ref.String(), normalized.String())
+				}
+			}
+		}
+	}
+
+	for _, testcase := range []struct {
+		input    string
+		expected string
+	}{
+		{input: "redis:7", expected: "docker.io/library/redis:7"},
+		{input: "index.docker.io/redis:7@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", expected: "docker.io/library/redis:7@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{input: "sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", expected: "sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+	} {
+		ref, err := ParseAnyReference(testcase.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key := CacheKey(ref); key != testcase.expected {
+			t.Errorf("%q: unexpected key: got %q, expected %q", testcase.input, key, testcase.expected)
+		}
+	}
+}
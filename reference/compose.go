@@ -0,0 +1,97 @@
+package reference
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ServiceError describes an image of a service that could not be parsed as
+// a reference.
+type ServiceError struct {
+	// Service is the name of the service.
+	Service string
+
+	// Image is the raw image reference of the service.
+	Image string
+
+	// Err is the error returned by the parser.
+	Err error
+}
+
+func (e ServiceError) Error() string {
+	return fmt.Sprintf("service %s: %q: %v", e.Service, e.Image, e.Err)
+}
+
+// Unwrap returns the underlying parse error.
+func (e ServiceError) Unwrap() error {
+	return e.Err
+}
+
+// ServiceErrors is a list of [ServiceError], ordered by service name.
+type ServiceErrors []ServiceError
+
+func (e ServiceErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// FindDuplicateImages finds images that are used by more than one service in
+// images, which maps service names to image references, as in a Compose
+// file. Images are normalized using [ParseDockerRef] before comparing them, so
+// that different spellings of the same image (such as "nginx" and
+// "docker.io/library/nginx:latest") are detected as duplicates.
+//
+// It returns a map of the normalized references of duplicate images to the
+// sorted names of the services using them. Images that cannot be parsed are
+// skipped, and reported in an error of type [ServiceErrors].
+func FindDuplicateImages(images map[string]string) (map[string][]string, error) {
+	services := make(map[string][]string)
+	var errs ServiceErrors
+	for service, image := range images {
+		named, err := ParseDockerRef(image)
+		if err != nil {
+			errs = append(errs, ServiceError{Service: service, Image: image, Err: err})
+			continue
+		}
+		services[named.String()] = append(services[named.String()], service)
+	}
+	duplicates := make(map[string][]string)
+	for ref, names := range services {
+		if len(names) > 1 {
+			sort.Strings(names)
+			duplicates[ref] = names
+		}
+	}
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Service < errs[j].Service })
+		return duplicates, errs
+	}
+	return duplicates, nil
+}
+
+// ParseComposeImages parses the image of each service in services, as in
+// the services section of a Compose file, using [ParseDockerRef]. It returns
+// the references of the images that could be parsed, and the errors for the
+// others, both keyed by service name; each error is a [ServiceError].
+// Services without an image (which are built rather than pulled) are
+// skipped. Both maps are non-nil.
+func ParseComposeImages(services map[string]struct{ Image string }) (map[string]Named, map[string]error) {
+	refs := make(map[string]Named, len(services))
+	errs := make(map[string]error)
+	for service, config := range services {
+		if config.Image == "" {
+			continue
+		}
+		named, err := ParseDockerRef(config.Image)
+		if err != nil {
+			errs[service] = ServiceError{Service: service, Image: config.Image, Err: err}
+			continue
+		}
+		refs[service] = named
+	}
+	return refs, errs
+}
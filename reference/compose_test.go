@@ -0,0 +1,118 @@
+package reference
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFindDuplicateImages(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name       string
+		images     map[string]string
+		duplicates map[string][]string
+		errors     []string
+	}{
+		{
+			name: "unique",
+			images: map[string]string{
+				"web":   "nginx:1.25",
+				"db":    "postgres:16",
+				"cache": "redis",
+			},
+			duplicates: map[string][]string{},
+		},
+		{
+			name: "duplicates",
+			images: map[string]string{
+				"web":    "nginx",
+				"proxy":  "docker.io/library/nginx:latest",
+				"static": "index.docker.io/nginx",
+				"api":    "example.com/api:1.0",
+				"worker": "example.com/api:1.0",
+				"admin":  "example.com/api:1.1",
+			},
+			duplicates: map[string][]string{
+				"docker.io/library/nginx:latest": {"proxy", "static", "web"},
+				"example.com/api:1.0":            {"api", "worker"},
+			},
+		},
+		{
+			name: "parse errors",
+			images: map[string]string{
+				"web":   "nginx",
+				"proxy": "nginx:latest",
+				"bad":   "Nginx",
+				"empty": "",
+			},
+			duplicates: map[string][]string{
+				"docker.io/library/nginx:latest": {"proxy", "web"},
+			},
+			errors: []string{"bad", "empty"},
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.name, func(t *testing.T) {
+			t.Parallel()
+			duplicates, err := FindDuplicateImages(testcase.images)
+			if !reflect.DeepEqual(duplicates, testcase.duplicates) {
+				t.Errorf("unexpected duplicates: got %v, expected %v", duplicates, testcase.duplicates)
+			}
+			if testcase.errors == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var errs ServiceErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("unexpected error type: %T", err)
+			}
+			var services []string
+			for _, e := range errs {
+				services = append(services, e.Service)
+			}
+			if !reflect.DeepEqual(services, testcase.errors) {
+				t.Errorf("unexpected errors: got %v, expected errors for %v", errs, testcase.errors)
+			}
+		})
+	}
+}
+
+func TestParseComposeImages(t *testing.T) {
+	t.Parallel()
+	services := map[string]struct{ Image string }{
+		"web":    {Image: "nginx:1.25"},
+		"proxy":  {Image: "docker.io/library/nginx"},
+		"db":     {Image: "postgres:16@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"},
+		"app":    {},
+		"broken": {Image: "Invalid:Image"},
+		"empty":  {Image: "nginx:"},
+	}
+	refs, errs := ParseComposeImages(services)
+
+	expected := map[string]string{
+		"web":   "docker.io/library/nginx:1.25",
+		"proxy": "docker.io/library/nginx:latest",
+		"db":    "docker.io/library/postgres@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+	}
+	actual := make(map[string]string, len(refs))
+	for service, named := range refs {
+		actual[service] = named.String()
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected references: got %v, expected %v", actual, expected)
+	}
+
+	if len(errs) != 2 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	for _, service := range []string{"broken", "empty"} {
+		var serviceErr ServiceError
+		if !errors.As(errs[service], &serviceErr) || serviceErr.Service != service || serviceErr.Image != services[service].Image {
+			t.Errorf("%s: unexpected error: %v", service, errs[service])
+		}
+	}
+}
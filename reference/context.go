@@ -0,0 +1,36 @@
+package reference
+
+import (
+	"context"
+	"fmt"
+)
+
+// fieldKey is the context key for the field name set by [WithField].
+type fieldKey struct{}
+
+// WithField returns a copy of ctx that carries the logical name of the field
+// being parsed (for example, "services.web.image"). Errors returned by
+// [ParseNamedContext] are prefixed with this name.
+func WithField(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, fieldKey{}, name)
+}
+
+// fieldFromContext returns the field name set by [WithField], if any.
+func fieldFromContext(ctx context.Context) string {
+	field, _ := ctx.Value(fieldKey{}).(string)
+	return field
+}
+
+// ParseNamedContext parses s using [ParseNamed]. If ctx carries a field name
+// set through [WithField], any error is wrapped so that its message includes
+// the field name. The original error can be matched using [errors.Is].
+func ParseNamedContext(ctx context.Context, s string) (Named, error) {
+	named, err := ParseNamed(s)
+	if err != nil {
+		if field := fieldFromContext(ctx); field != "" {
+			return nil, fmt.Errorf("%s: %w", field, err)
+		}
+		return nil, err
+	}
+	return named, nil
+}
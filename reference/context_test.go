@@ -0,0 +1,73 @@
+package reference
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseNamedContext(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name  string
+		field string
+		input string
+		err   error
+	}{
+		{
+			name:  "valid",
+			field: "image",
+			input: "docker.io/library/foo",
+		},
+		{
+			name:  "not canonical with field",
+			field: "services.web.image",
+			input: "foo",
+			err:   ErrNameNotCanonical,
+		},
+		{
+			name:  "invalid format with field",
+			field: "base",
+			input: "docker.io/foo/-bar",
+			err:   ErrReferenceInvalidFormat,
+		},
+		{
+			name:  "not canonical without field",
+			input: "foo",
+			err:   ErrNameNotCanonical,
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			if testcase.field != "" {
+				ctx = WithField(ctx, testcase.field)
+			}
+			named, err := ParseNamedContext(ctx, testcase.input)
+			if testcase.err == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if named.String() != testcase.input {
+					t.Errorf("unexpected reference: got %q, expected %q", named.String(), testcase.input)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("parsing succeeded: expected error %v", testcase.err)
+			}
+			if !errors.Is(err, testcase.err) {
+				t.Errorf("unexpected error %v, expected %v", err, testcase.err)
+			}
+			if testcase.field != "" && !strings.Contains(err.Error(), testcase.field) {
+				t.Errorf("expected error %q to include field %q", err.Error(), testcase.field)
+			}
+			if testcase.field == "" && err != testcase.err {
+				t.Errorf("expected unwrapped error %v, got %v", testcase.err, err)
+			}
+		})
+	}
+}
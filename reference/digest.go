@@ -0,0 +1,235 @@
+package reference
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// ErrDigestRequired is returned when a reference is required to have a
+// digest, but has none.
+var ErrDigestRequired = errors.New("reference must have a digest")
+
+// ErrDigestNotFound is returned by [PinAll] for references whose repository
+// has no digest.
+var ErrDigestNotFound = errors.New("no digest found for repository")
+
+// UnavailableAlgorithmError is returned when a digest uses an algorithm that
+// is not registered with, or not available in, the go-digest package. It
+// matches [digest.ErrDigestUnsupported] when used with [errors.Is].
+type UnavailableAlgorithmError struct {
+	Algorithm digest.Algorithm
+}
+
+func (e UnavailableAlgorithmError) Error() string {
+	return fmt.Sprintf("digest algorithm %q is not available", string(e.Algorithm))
+}
+
+// Is reports whether target is [digest.ErrDigestUnsupported].
+func (e UnavailableAlgorithmError) Is(target error) bool {
+	return target == digest.ErrDigestUnsupported
+}
+
+// CheckAlgorithmAvailable returns an [UnavailableAlgorithmError] if the
+// algorithm of dgst is not available. [WithDigest] only checks that a digest
+// is syntactically valid; callers that need to verify content using the
+// digest can use this function, or [WithDigestRequireAvailable], to fail
+// early.
+func CheckAlgorithmAvailable(dgst digest.Digest) error {
+	if algorithm := dgst.Algorithm(); !algorithm.Available() {
+		return UnavailableAlgorithmError{Algorithm: algorithm}
+	}
+	return nil
+}
+
+// ParseRequireAvailableAlgorithm parses s in the same way as [Parse]. [Parse]
+// already rejects digests with an algorithm that is not available, with
+// [digest.ErrDigestUnsupported]; ParseRequireAvailableAlgorithm only returns
+// the typed [UnavailableAlgorithmError] instead, so that callers can report
+// the algorithm.
+func ParseRequireAvailableAlgorithm(s string) (Reference, error) {
+	if matches := ReferenceRegexp.FindStringSubmatch(s); matches != nil && matches[3] != "" {
+		if err := CheckAlgorithmAvailable(digest.Digest(matches[3])); err != nil {
+			return nil, err
+		}
+	}
+	return Parse(s)
+}
+
+// WithDigestRequireAvailable combines name with dgst in the same way as
+// [WithDigest], but returns an [UnavailableAlgorithmError] if the algorithm
+// of dgst is not available.
+func WithDigestRequireAvailable(name Named, dgst digest.Digest) (Canonical, error) {
+	if err := CheckAlgorithmAvailable(dgst); err != nil {
+		return nil, err
+	}
+	return WithDigest(name, dgst)
+}
+
+// ParseCanonicalStrict parses s in the same way as
+// [ParseRequireAvailableAlgorithm], and requires the reference to be a
+// [Canonical] reference with a name and a digest. As with [Parse], the digest
+// must be in the canonical form as validated by go-digest: the encoded part
+// must be valid for the algorithm (for example, lowercase hex for "sha256").
+// [ErrDigestRequired] is returned if s has no digest.
+func ParseCanonicalStrict(s string) (Canonical, error) {
+	ref, err := ParseRequireAvailableAlgorithm(s)
+	if err != nil {
+		return nil, err
+	}
+	canonical, ok := ref.(Canonical)
+	if !ok {
+		return nil, ErrDigestRequired
+	}
+	return canonical, nil
+}
+
+// PinAll pins each reference in refs to the digest of its repository in
+// digests, which is keyed by [RepositoryKey], using [WithDigest]. Tags are
+// preserved.
+//
+// Both returned slices have the same length as refs: for each index, either
+// the pinned reference or the error is set. An error wrapping
+// [ErrDigestNotFound] is returned for references whose repository is not in
+// digests, unless the reference is already pinned, in which case it is
+// returned as-is. References that are already pinned to a different digest
+// than the one in digests result in an error.
+func PinAll(refs []Named, digests map[string]digest.Digest) ([]Canonical, []error) {
+	pinned := make([]Canonical, len(refs))
+	errs := make([]error, len(refs))
+	for i, ref := range refs {
+		dgst, ok := digests[RepositoryKey(ref)]
+		canonical, isCanonical := ref.(Canonical)
+		switch {
+		case !ok && isCanonical:
+			pinned[i] = canonical
+		case !ok:
+			errs[i] = fmt.Errorf("%s: %w", ref.String(), ErrDigestNotFound)
+		case isCanonical && canonical.Digest() != dgst:
+			errs[i] = fmt.Errorf("%s: already pinned to a different digest than %s", ref.String(), dgst)
+		default:
+			pinned[i], errs[i] = WithDigest(ref, dgst)
+		}
+	}
+	return pinned, errs
+}
+
+// DigestsCompatible reports whether a and b could refer to the same image,
+// by comparing their digests: it returns true if the digests are equal, and
+// false if they differ. Names and tags are not compared. An error wrapping
+// [ErrDigestRequired] is returned if either reference has no digest.
+func DigestsCompatible(a, b Reference) (bool, error) {
+	da, ok := a.(Digested)
+	if !ok {
+		return false, fmt.Errorf("%s: %w", a.String(), ErrDigestRequired)
+	}
+	db, ok := b.(Digested)
+	if !ok {
+		return false, fmt.Errorf("%s: %w", b.String(), ErrDigestRequired)
+	}
+	return da.Digest() == db.Digest(), nil
+}
+
+// PinnedAndTagged returns both forms of a reference that has a digest: the
+// canonical reference with only the name and digest, for use as a storage
+// key, and the familiar string with the tag (if any) but without the digest,
+// for display. For example, for "docker.io/library/nginx:1.25@sha256:<hex>"
+// it returns "docker.io/library/nginx@sha256:<hex>" and "nginx:1.25". If ref
+// has no tag, the display string is the familiar form of the pinned
+// reference. An error wrapping [ErrDigestRequired] is returned if ref has no
+// digest, and an error is returned if ref has no name.
+func PinnedAndTagged(ref Reference) (pinned Canonical, display string, err error) {
+	digested, ok := ref.(Digested)
+	if !ok {
+		return nil, "", fmt.Errorf("%s: %w", ref.String(), ErrDigestRequired)
+	}
+	named, ok := ref.(Named)
+	if !ok {
+		return nil, "", fmt.Errorf("reference %s has no name", ref.String())
+	}
+	pinned, err = WithDigest(TrimNamed(named), digested.Digest())
+	if err != nil {
+		return nil, "", err
+	}
+	if tagged, ok := ref.(Tagged); ok {
+		return pinned, FamiliarName(named) + ":" + tagged.Tag(), nil
+	}
+	return pinned, FamiliarString(pinned), nil
+}
+
+// DigestAlgorithm returns the algorithm of the digest of ref (such as
+// [digest.SHA256]), and true if ref has a digest.
+func DigestAlgorithm(ref Reference) (digest.Algorithm, bool) {
+	digested, ok := ref.(Digested)
+	if !ok {
+		return "", false
+	}
+	return digested.Digest().Algorithm(), true
+}
+
+// ParseLowercaseDigest parses s in the same way as [Parse], but accepts
+// digests with uppercase hexadecimal characters, as emitted by some tools, by
+// converting them to lowercase before validation. For example,
+// "example.com/app@sha256:ABCD..." is parsed as "example.com/app@sha256:abcd...".
+func ParseLowercaseDigest(s string) (Reference, error) {
+	if i := strings.LastIndexByte(s, '@'); i != -1 {
+		if j := strings.IndexByte(s[i:], ':'); j != -1 {
+			s = s[:i+j] + strings.ToLower(s[i+j:])
+		}
+	}
+	return Parse(s)
+}
+
+// SimplifyRedundant returns ref without its tag if ref has both a tag and a
+// digest and keepTag is false, for when the digest is authoritative and the
+// tag only serves as a hint. Otherwise, including for references that only
+// have a name, a tag, or a digest, ref is returned unchanged.
+func SimplifyRedundant(ref Reference, keepTag bool) Reference {
+	if keepTag {
+		return ref
+	}
+	named, isNamed := ref.(Named)
+	_, isTagged := ref.(Tagged)
+	digested, isDigested := ref.(Digested)
+	if !isNamed || !isTagged || !isDigested {
+		return ref
+	}
+	simplified, err := WithDigest(TrimNamed(named), digested.Digest())
+	if err != nil {
+		// The digest of ref was already validated.
+		return ref
+	}
+	return simplified
+}
+
+// ErrDigestAlgorithmMismatch is returned by [ValidDigestFor] for digests
+// that use another algorithm than the expected one.
+var ErrDigestAlgorithmMismatch = errors.New("digest algorithm does not match")
+
+// ValidDigestFor returns an error if dgst is not a valid digest using algo,
+// for example, when combining a digest with a reference from separate
+// sources. An error matching [ErrDigestAlgorithmMismatch] is returned if
+// dgst uses another algorithm; otherwise, dgst is validated by go-digest, so
+// the encoded part must be valid for algo, and algo must be available.
+func ValidDigestFor(dgst string, algo digest.Algorithm) error {
+	algorithm, _, ok := strings.Cut(dgst, ":")
+	if !ok {
+		return digest.ErrDigestInvalidFormat
+	}
+	if digest.Algorithm(algorithm) != algo {
+		return fmt.Errorf("%w: %q, expected %q", ErrDigestAlgorithmMismatch, algorithm, string(algo))
+	}
+	return digest.Digest(dgst).Validate()
+}
+
+// WithDigestAlgo combines the name from "name" and the digest dgst, as done
+// by [WithDigest], after checking that dgst is a valid digest using algo
+// with [ValidDigestFor].
+func WithDigestAlgo(name Named, dgst string, algo digest.Algorithm) (Canonical, error) {
+	if err := ValidDigestFor(dgst, algo); err != nil {
+		return nil, err
+	}
+	return WithDigest(name, digest.Digest(dgst))
+}
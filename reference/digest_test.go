@@ -0,0 +1,482 @@
+package reference
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func TestParseRequireAvailableAlgorithm(t *testing.T) {
+	t.Parallel()
+	const (
+		available   = "example.com/foo@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+		unavailable = "example.com/foo@md5:8b1a9953c4611296a827abf8c47804d7"
+	)
+
+	ref, err := ParseRequireAvailableAlgorithm(available)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.String() != available {
+		t.Errorf("unexpected: got %q, expected %q", ref.String(), available)
+	}
+
+	_, err = ParseRequireAvailableAlgorithm(unavailable)
+	var algErr UnavailableAlgorithmError
+	if !errors.As(err, &algErr) {
+		t.Fatalf("expected UnavailableAlgorithmError, got %v", err)
+	}
+	if algErr.Algorithm != "md5" {
+		t.Errorf("unexpected algorithm: got %q, expected %q", algErr.Algorithm, "md5")
+	}
+	if !errors.Is(err, digest.ErrDigestUnsupported) {
+		t.Errorf("expected error to match %v", digest.ErrDigestUnsupported)
+	}
+
+	if _, err := ParseRequireAvailableAlgorithm("example.com/foo:tag"); err != nil {
+		t.Errorf("unexpected error for reference without digest: %v", err)
+	}
+
+	// Parse already rejects the digest, without the typed error.
+	if _, err := Parse(unavailable); !errors.Is(err, digest.ErrDigestUnsupported) {
+		t.Errorf("expected Parse error to match %v, got %v", digest.ErrDigestUnsupported, err)
+	}
+}
+
+func TestCheckAlgorithmAvailable(t *testing.T) {
+	t.Parallel()
+	named, err := WithName("example.com/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// WithDigest only checks the syntax of the digest by default.
+	unavailable := digest.Digest("md5:8b1a9953c4611296a827abf8c47804d7")
+	if _, err := WithDigest(named, unavailable); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := CheckAlgorithmAvailable(unavailable); !errors.As(err, &UnavailableAlgorithmError{}) {
+		t.Errorf("expected UnavailableAlgorithmError, got %v", err)
+	}
+	if err := CheckAlgorithmAvailable(digest.FromString("foo")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWithDigestRequireAvailable(t *testing.T) {
+	t.Parallel()
+	named, err := WithName("example.com/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dgst := digest.FromString("foo")
+	canonical, err := WithDigestRequireAvailable(named, dgst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "example.com/foo@" + dgst.String(); canonical.String() != expected {
+		t.Errorf("unexpected: got %q, expected %q", canonical.String(), expected)
+	}
+
+	_, err = WithDigestRequireAvailable(named, digest.Digest("md5:8b1a9953c4611296a827abf8c47804d7"))
+	var algErr UnavailableAlgorithmError
+	if !errors.As(err, &algErr) {
+		t.Fatalf("expected UnavailableAlgorithmError, got %v", err)
+	}
+	if algErr.Algorithm != "md5" {
+		t.Errorf("unexpected algorithm: got %q, expected %q", algErr.Algorithm, "md5")
+	}
+}
+
+func TestParseCanonicalStrict(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input string
+		err   error
+	}{
+		{
+			input: "example.com/foo@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+		},
+		{
+			input: "example.com/foo:tag@sha512:cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e",
+		},
+		{
+			input: "example.com/foo@sha256:86E0E091D0DA6BDE2456DBB48306F3956BBEB2EAE1B5B9A43045843F69FE4AAA",
+			err:   digest.ErrDigestInvalidFormat,
+		},
+		{
+			input: "example.com/foo@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aa",
+			err:   digest.ErrDigestInvalidLength,
+		},
+		{
+			input: "example.com/foo@md5:8b1a9953c4611296a827abf8c47804d7",
+			err:   digest.ErrDigestUnsupported,
+		},
+		{
+			input: "example.com/foo:tag",
+			err:   ErrDigestRequired,
+		},
+		{
+			input: "sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+			err:   ErrDigestRequired,
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			canonical, err := ParseCanonicalStrict(testcase.input)
+			if testcase.err != nil {
+				if !errors.Is(err, testcase.err) {
+					t.Errorf("expected %v, got %v", testcase.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if canonical.String() != testcase.input {
+				t.Errorf("unexpected: got %q, expected %q", canonical.String(), testcase.input)
+			}
+		})
+	}
+}
+
+func TestPinAll(t *testing.T) {
+	t.Parallel()
+	const (
+		dgstNginx = digest.Digest("sha256:1111111111111111111111111111111111111111111111111111111111111111")
+		dgstApp   = digest.Digest("sha256:2222222222222222222222222222222222222222222222222222222222222222")
+	)
+	digests := map[string]digest.Digest{
+		"docker.io/library/nginx": dgstNginx,
+		"example.com/app":         dgstApp,
+	}
+	testcases := []struct {
+		name     string
+		refs     []string
+		expected []string
+		errs     []error
+	}{
+		{
+			name:     "all found",
+			refs:     []string{"nginx:1.25", "example.com/app", "index.docker.io/library/nginx"},
+			expected: []string{"docker.io/library/nginx:1.25@" + string(dgstNginx), "example.com/app@" + string(dgstApp), "docker.io/library/nginx@" + string(dgstNginx)},
+			errs:     []error{nil, nil, nil},
+		},
+		{
+			name:     "partial miss",
+			refs:     []string{"nginx", "example.com/missing:1.0", "example.com/app:1.0"},
+			expected: []string{"docker.io/library/nginx@" + string(dgstNginx), "", "example.com/app:1.0@" + string(dgstApp)},
+			errs:     []error{nil, ErrDigestNotFound, nil},
+		},
+		{
+			name:     "already pinned",
+			refs:     []string{"example.com/app:1.0@" + string(dgstApp), "example.com/missing@" + string(dgstNginx)},
+			expected: []string{"example.com/app:1.0@" + string(dgstApp), "example.com/missing@" + string(dgstNginx)},
+			errs:     []error{nil, nil},
+		},
+		{
+			name:     "already pinned to a different digest",
+			refs:     []string{"example.com/app@" + string(dgstNginx)},
+			expected: []string{""},
+			errs:     []error{errors.New("")},
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.name, func(t *testing.T) {
+			t.Parallel()
+			refs := make([]Named, 0, len(testcase.refs))
+			for _, s := range testcase.refs {
+				ref, err := ParseNormalizedNamed(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				refs = append(refs, ref)
+			}
+			pinned, errs := PinAll(refs, digests)
+			if len(pinned) != len(refs) || len(errs) != len(refs) {
+				t.Fatalf("unexpected lengths: got %d and %d, expected %d", len(pinned), len(errs), len(refs))
+			}
+			for i := range refs {
+				switch expectedErr := testcase.errs[i]; {
+				case expectedErr == nil && errs[i] != nil:
+					t.Errorf("%d: unexpected error: %v", i, errs[i])
+				case expectedErr != nil && errs[i] == nil:
+					t.Errorf("%d: expected error, got %v", i, pinned[i])
+				case expectedErr == ErrDigestNotFound && !errors.Is(errs[i], ErrDigestNotFound):
+					t.Errorf("%d: unexpected error: got %v, expected %v", i, errs[i], ErrDigestNotFound)
+				}
+				if testcase.expected[i] == "" {
+					if pinned[i] != nil {
+						t.Errorf("%d: unexpected reference: %v", i, pinned[i])
+					}
+				} else if pinned[i] == nil || pinned[i].String() != testcase.expected[i] {
+					t.Errorf("%d: unexpected reference: got %v, expected %s", i, pinned[i], testcase.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDigestsCompatible(t *testing.T) {
+	t.Parallel()
+	const (
+		dgst1 = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		dgst2 = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	)
+	testcases := []struct {
+		a, b       string
+		compatible bool
+		err        error
+	}{
+		{a: "example.com/app@" + dgst1, b: "example.com/app@" + dgst1, compatible: true},
+		{a: "example.com/app:1.0@" + dgst1, b: "example.com/mirror/app@" + dgst1, compatible: true},
+		{a: dgst1, b: "example.com/app@" + dgst1, compatible: true},
+		{a: "example.com/app@" + dgst1, b: "example.com/app@" + dgst2, compatible: false},
+		{a: "example.com/app:1.0", b: "example.com/app@" + dgst1, err: ErrDigestRequired},
+		{a: "example.com/app@" + dgst1, b: "example.com/app", err: ErrDigestRequired},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.a+" "+testcase.b, func(t *testing.T) {
+			t.Parallel()
+			a, err := ParseAnyReference(testcase.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ParseAnyReference(testcase.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			compatible, err := DigestsCompatible(a, b)
+			if testcase.err != nil {
+				if !errors.Is(err, testcase.err) {
+					t.Fatalf("unexpected error: got %v, expected %v", err, testcase.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if compatible != testcase.compatible {
+				t.Errorf("unexpected result: got %v, expected %v", compatible, testcase.compatible)
+			}
+		})
+	}
+}
+
+func TestPinnedAndTagged(t *testing.T) {
+	t.Parallel()
+	const dgst = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	testcases := []struct {
+		input   string
+		pinned  string
+		display string
+		err     error
+	}{
+		{input: "nginx:1.25@" + dgst, pinned: "docker.io/library/nginx@" + dgst, display: "nginx:1.25"},
+		{input: "example.com/app:1.0@" + dgst, pinned: "example.com/app@" + dgst, display: "example.com/app:1.0"},
+		{input: "nginx@" + dgst, pinned: "docker.io/library/nginx@" + dgst, display: "nginx@" + dgst},
+		{input: "nginx:1.25", err: ErrDigestRequired},
+		{input: dgst, err: errors.New("no name")},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseAnyReference(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pinned, display, err := PinnedAndTagged(ref)
+			if testcase.err != nil {
+				if err == nil {
+					t.Fatalf("expected error, got %v %q", pinned, display)
+				}
+				if testcase.err == ErrDigestRequired && !errors.Is(err, ErrDigestRequired) {
+					t.Fatalf("unexpected error: got %v, expected %v", err, testcase.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pinned.String() != testcase.pinned {
+				t.Errorf("unexpected pinned reference: got %q, expected %q", pinned.String(), testcase.pinned)
+			}
+			if display != testcase.display {
+				t.Errorf("unexpected display string: got %q, expected %q", display, testcase.display)
+			}
+		})
+	}
+}
+
+func TestDigestAlgorithm(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input     string
+		algorithm digest.Algorithm
+	}{
+		{input: "example.com/app@sha256:1111111111111111111111111111111111111111111111111111111111111111", algorithm: digest.SHA256},
+		{input: "example.com/app:1.0@sha512:" + strings.Repeat("1", 128), algorithm: digest.SHA512},
+		{input: "sha256:1111111111111111111111111111111111111111111111111111111111111111", algorithm: digest.SHA256},
+		{input: "example.com/app:1.0"},
+		{input: "example.com/app"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseAnyReference(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			algorithm, ok := DigestAlgorithm(ref)
+			if algorithm != testcase.algorithm || ok != (testcase.algorithm != "") {
+				t.Errorf("unexpected result: got (%q, %v), expected %q", algorithm, ok, testcase.algorithm)
+			}
+		})
+	}
+}
+
+func TestParseLowercaseDigest(t *testing.T) {
+	t.Parallel()
+	const (
+		upper = "sha256:86E0E091D0DA6BDE2456DBB48306F3956BBEB2EAE1B5B9A43045843F69FE4AAA"
+		lower = "sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+	)
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "example.com/foo@" + upper, expected: "example.com/foo@" + lower},
+		{input: "example.com/foo:TAG@" + upper, expected: "example.com/foo:TAG@" + lower},
+		{input: "example.com/foo@" + lower, expected: "example.com/foo@" + lower},
+		{input: "example.com/foo:TAG", expected: "example.com/foo:TAG"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseLowercaseDigest(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ref.String() != testcase.expected {
+				t.Errorf("unexpected reference: got %q, expected %q", ref.String(), testcase.expected)
+			}
+		})
+	}
+
+	// The strict parser and WithDigest reject uppercase digests.
+	if _, err := Parse("example.com/foo@" + upper); !errors.Is(err, UppercaseDigestError{}) || !errors.Is(err, digest.ErrDigestInvalidFormat) {
+		t.Errorf("unexpected error from Parse: %v", err)
+	}
+	named, err := WithName("example.com/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WithDigest(named, upper); !errors.Is(err, UppercaseDigestError{}) || !errors.Is(err, ErrDigestInvalidFormat) {
+		t.Errorf("unexpected error from WithDigest: %v", err)
+	}
+	if _, err := WithDigest(named, lower); err != nil {
+		t.Errorf("unexpected error from WithDigest: %v", err)
+	}
+}
+
+func TestSimplifyRedundant(t *testing.T) {
+	t.Parallel()
+	const dgst = "sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+	testcases := []struct {
+		input    string
+		keepTag  bool
+		expected string
+	}{
+		{input: "docker.io/library/nginx:1.25@" + dgst, expected: "docker.io/library/nginx@" + dgst},
+		{input: "docker.io/library/nginx:1.25@" + dgst, keepTag: true, expected: "docker.io/library/nginx:1.25@" + dgst},
+		{input: "docker.io/library/nginx@" + dgst, expected: "docker.io/library/nginx@" + dgst},
+		{input: "docker.io/library/nginx@" + dgst, keepTag: true, expected: "docker.io/library/nginx@" + dgst},
+		{input: "docker.io/library/nginx:1.25", expected: "docker.io/library/nginx:1.25"},
+		{input: "docker.io/library/nginx:1.25", keepTag: true, expected: "docker.io/library/nginx:1.25"},
+		{input: "docker.io/library/nginx", expected: "docker.io/library/nginx"},
+		{input: "docker.io/library/nginx", keepTag: true, expected: "docker.io/library/nginx"},
+		{input: dgst, expected: dgst},
+		{input: dgst, keepTag: true, expected: dgst},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input+"/"+strconv.FormatBool(testcase.keepTag), func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseAnyReference(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			simplified := SimplifyRedundant(ref, testcase.keepTag)
+			if simplified.String() != testcase.expected {
+				t.Errorf("unexpected reference: got %q, expected %q", simplified.String(), testcase.expected)
+			}
+			if _, ok := simplified.(Tagged); ok && !testcase.keepTag && strings.Contains(testcase.input, "@") {
+				t.Errorf("unexpected tagged reference %q", simplified.String())
+			}
+		})
+	}
+}
+
+func TestValidDigestFor(t *testing.T) {
+	t.Parallel()
+	sha256Digest := digest.FromString("app").String()
+	sha512Digest := digest.SHA512.FromString("app").String()
+	testcases := []struct {
+		dgst string
+		algo digest.Algorithm
+		err  error
+	}{
+		{dgst: sha256Digest, algo: digest.SHA256},
+		{dgst: sha512Digest, algo: digest.SHA512},
+		{dgst: sha256Digest, algo: digest.SHA512, err: ErrDigestAlgorithmMismatch},
+		{dgst: sha512Digest, algo: digest.SHA256, err: ErrDigestAlgorithmMismatch},
+		{dgst: "sha256:abc", algo: digest.SHA256, err: digest.ErrDigestInvalidLength},
+		{dgst: strings.ToUpper(sha256Digest[:7]) + sha256Digest[7:], algo: digest.SHA256, err: ErrDigestAlgorithmMismatch},
+		{dgst: sha256Digest[:7] + strings.ToUpper(sha256Digest[7:]), algo: digest.SHA256, err: digest.ErrDigestInvalidFormat},
+		{dgst: "not-a-digest", algo: digest.SHA256, err: digest.ErrDigestInvalidFormat},
+		{dgst: "", algo: digest.SHA256, err: digest.ErrDigestInvalidFormat},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.dgst+"/"+string(testcase.algo), func(t *testing.T) {
+			t.Parallel()
+			err := ValidDigestFor(testcase.dgst, testcase.algo)
+			if testcase.err == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if !errors.Is(err, testcase.err) {
+				t.Errorf("unexpected error: got %v, expected %v", err, testcase.err)
+			}
+
+			named := mustParseNormalized(t, "example.com/app:1.0")
+			canonical, err := WithDigestAlgo(named, testcase.dgst, testcase.algo)
+			if testcase.err != nil {
+				if err == nil {
+					t.Errorf("WithDigestAlgo: expected error, got %q", canonical.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := "example.com/app:1.0@" + testcase.dgst; canonical.String() != expected {
+				t.Errorf("WithDigestAlgo: got %q, expected %q", canonical.String(), expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package reference
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// scratch is the name of the reserved, empty base image in Dockerfiles.
+const scratch = "scratch"
+
+// ErrScratch is returned by [ParseFromInstruction] for the reserved "scratch"
+// base image.
+var ErrScratch = errors.New(`"scratch" is a reserved name for an empty base image, not an image reference`)
+
+// ErrTemplatedReference is returned by [ParseLenient] for references that
+// contain unexpanded variables (see [IsTemplated]).
+var ErrTemplatedReference = errors.New("reference contains unexpanded variables")
+
+// templateVariableRegexp matches "$VAR" and "${...}" variable references.
+var templateVariableRegexp = regexp.MustCompile(`\$(?:\{[^}]*\}|[A-Za-z_][A-Za-z0-9_]*)`)
+
+// IsTemplated reports whether s contains unexpanded variables in the "$VAR"
+// or "${VAR}" form (including modifiers such as "${VAR:-default}"), as used
+// for build arguments in Dockerfiles, for example "${REGISTRY}/app:${TAG}".
+func IsTemplated(s string) bool {
+	return templateVariableRegexp.MatchString(s)
+}
+
+// IsScratch reports whether s is the reserved "scratch" base image used in
+// Dockerfiles to start from an empty filesystem. The comparison is
+// case-insensitive.
+//
+// Although "scratch" is syntactically a valid reference, it does not refer to
+// an image that can be pulled, and must not be normalized to
+// "docker.io/library/scratch".
+func IsScratch(s string) bool {
+	return strings.EqualFold(s, scratch)
+}
+
+// ParseFromInstruction parses the image of a Dockerfile FROM instruction in
+// the same way as [ParseDockerRef]. [ErrScratch] is returned if the image is
+// the reserved "scratch" base image (see [IsScratch]).
+func ParseFromInstruction(s string) (Named, error) {
+	if IsScratch(s) {
+		return nil, ErrScratch
+	}
+	return ParseDockerRef(s)
+}
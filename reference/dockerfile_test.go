@@ -0,0 +1,75 @@
+package reference
+
+import (
+	"testing"
+)
+
+func TestParseFromInstruction(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		scratch  bool
+		expected string
+	}{
+		{input: "scratch", scratch: true},
+		{input: "Scratch", scratch: true},
+		{input: "SCRATCH", scratch: true},
+		{input: "busybox", expected: "docker.io/library/busybox:latest"},
+		{input: "library/scratch", expected: "docker.io/library/scratch:latest"},
+		{input: "example.com/scratch:1.0", expected: "example.com/scratch:1.0"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			if actual := IsScratch(testcase.input); actual != testcase.scratch {
+				t.Errorf("IsScratch: expected %v, got %v", testcase.scratch, actual)
+			}
+			named, err := ParseFromInstruction(testcase.input)
+			if testcase.scratch {
+				if err != ErrScratch {
+					t.Errorf("expected %v, got %v", ErrScratch, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if named.String() != testcase.expected {
+				t.Errorf("unexpected: got %q, expected %q", named.String(), testcase.expected)
+			}
+		})
+	}
+}
+
+func TestIsTemplated(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input     string
+		templated bool
+	}{
+		{input: "${REGISTRY}/app:1.0", templated: true},
+		{input: "$REGISTRY/app:1.0", templated: true},
+		{input: "example.com/app:${TAG}", templated: true},
+		{input: "example.com/app:$TAG", templated: true},
+		{input: "example.com/${APP:-app}:1.0", templated: true},
+		{input: "example.com/app:1.0", templated: false},
+		{input: "app", templated: false},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			if actual := IsTemplated(testcase.input); actual != testcase.templated {
+				t.Errorf("expected %v, got %v", testcase.templated, actual)
+			}
+			_, err := ParseLenient(testcase.input)
+			if testcase.templated && err != ErrTemplatedReference {
+				t.Errorf("expected %v, got %v", ErrTemplatedReference, err)
+			}
+			if !testcase.templated && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package reference
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// defaultRegistryHost is the host of the Docker Hub registry API, which
+// serves images for the default domain.
+const defaultRegistryHost = "registry-1.docker.io"
+
+// ErrPortInvalid is returned when a port is not a number between 1 and 65535.
+var ErrPortInvalid = errors.New("port must be a number between 1 and 65535")
+
+// splitHostPort splits a domain into its host and port. The port is empty if
+// the domain has no port. Unlike [net.SplitHostPort], the brackets of an IPv6
+// host are preserved.
+func splitHostPort(domain string) (host, port string) {
+	i := strings.LastIndexByte(domain, ':')
+	if i == -1 || strings.LastIndexByte(domain, ']') > i {
+		return domain, ""
+	}
+	return domain[:i], domain[i+1:]
+}
+
+// Port returns the port of the domain of ref, and true if the domain has a
+// port.
+func Port(ref Named) (string, bool) {
+	_, port := splitHostPort(Domain(ref))
+	return port, port != ""
+}
+
+// WithPort returns a reference with the port of the domain of ref set to
+// port, replacing the existing port (if any). The tag and digest of ref are
+// preserved. An error is returned if ref has no domain, if port is not a
+// number between 1 and 65535 without leading zeros, or if the resulting name
+// is longer than [NameTotalLengthMax].
+func WithPort(ref Named, port string) (Named, error) {
+	if n, err := strconv.ParseUint(port, 10, 16); err != nil || n == 0 || port[0] == '0' {
+		return nil, ErrPortInvalid
+	}
+	domain := Domain(ref)
+	if domain == "" {
+		return nil, ErrReferenceInvalidFormat
+	}
+	host, _ := splitHostPort(domain)
+	name, err := Join(host+":"+port, Path(ref))
+	if err != nil {
+		return nil, err
+	}
+	return withRepository(ref, name.(repository)), nil
+}
+
+// RegistryHost returns the host to connect to for the registry API of the
+// domain of ref. The Docker Hub domains ("docker.io" and "index.docker.io")
+// are translated to "registry-1.docker.io"; other domains are returned
+// unchanged, including their port (if any).
+func RegistryHost(ref Named) string {
+	switch domain := Domain(ref); domain {
+	case defaultDomain, legacyDefaultDomain:
+		return defaultRegistryHost
+	default:
+		return domain
+	}
+}
+
+// CanonicalDomain returns the canonical form of domain, translating the
+// aliases of Docker Hub ("index.docker.io" and "registry-1.docker.io") to
+// "docker.io". Other domains are returned unchanged.
+func CanonicalDomain(domain string) string {
+	switch domain {
+	case legacyDefaultDomain, defaultRegistryHost:
+		return defaultDomain
+	default:
+		return domain
+	}
+}
+
+// BlockedDomain reports whether the domain of ref matches any of the rules
+// in blocked, and returns the first matching rule. Rules are matched
+// case-insensitively against the canonical domain (see [CanonicalDomain]),
+// so that all aliases of Docker Hub are matched by "docker.io":
+//
+//   - A rule starting with "." matches any subdomain of the rule, regardless
+//     of the port; for example, ".internal" matches "registry.internal:5000".
+//   - A rule with a port matches the domain with that port only.
+//   - Any other rule matches the host, regardless of the port.
+func BlockedDomain(ref Named, blocked []string) (bool, string) {
+	domain := CanonicalDomain(Domain(ref))
+	host, _ := splitHostPort(domain)
+	for _, rule := range blocked {
+		switch _, port := splitHostPort(rule); {
+		case strings.HasPrefix(rule, "."):
+			if len(host) > len(rule) && strings.EqualFold(host[len(host)-len(rule):], rule) {
+				return true, rule
+			}
+		case port != "":
+			if strings.EqualFold(domain, rule) {
+				return true, rule
+			}
+		default:
+			if strings.EqualFold(host, CanonicalDomain(rule)) {
+				return true, rule
+			}
+		}
+	}
+	return false, ""
+}
+
+// DisambiguatePort reports whether s, a familiar or fully-qualified
+// reference, starts with a domain with a port ("host:port"), rather than a
+// repository with a tag ("repo:tag").
+//
+// A ":" in the first component is only part of a domain if that component
+// is followed by a "/"; otherwise, it separates the tag. For example,
+// "192.168.0.1:8/debian" is the repository "debian" on the registry at
+// "192.168.0.1:8", but "192.168.0.1:80" is the repository "192.168.0.1"
+// (normalized to "docker.io/library/192.168.0.1") with the tag "80". The
+// reference is parsed as by [ParseNormalizedNamed], and its error is returned
+// if s is invalid.
+func DisambiguatePort(s string) (isHostPort bool, err error) {
+	named, err := parseNormalized(s)
+	if err != nil {
+		return false, err
+	}
+	_, isHostPort = Port(named)
+	return isHostPort, nil
+}
+
+// ParseWithAliases parses s as done by [ParseNormalizedNamed], after
+// replacing the first component of s with the registry domain it is an alias
+// for in aliases, if any; for example, with the alias "internal" for
+// "registry.corp.example.com", "internal/team/app:1.0" is parsed as
+// "registry.corp.example.com/team/app:1.0". The first component is only
+// replaced if it is followed by a "/", so bare names such as "internal" are
+// not affected. Aliases take precedence over Docker Hub namespaces with the
+// same name.
+func ParseWithAliases(s string, aliases map[string]string) (Named, error) {
+	if first, remainder, ok := strings.Cut(s, "/"); ok {
+		if domain, ok := aliases[first]; ok {
+			s = domain + "/" + remainder
+		}
+	}
+	return parseNormalized(s)
+}
@@ -0,0 +1,302 @@
+package reference
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPort(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input string
+		port  string
+	}{
+		{input: "example.com/foo"},
+		{input: "example.com:5000/foo", port: "5000"},
+		{input: "localhost:8080/foo:tag", port: "8080"},
+		{input: "[fc00::1]/foo"},
+		{input: "[fc00::1]:5000/foo", port: "5000"},
+		{input: "foo"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			port, ok := Port(ref)
+			if port != testcase.port || ok != (testcase.port != "") {
+				t.Errorf("unexpected port: got (%q, %v), expected %q", port, ok, testcase.port)
+			}
+		})
+	}
+}
+
+func TestWithPort(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		port     string
+		expected string
+		err      error
+	}{
+		{
+			input:    "example.com/foo:tag",
+			port:     "5000",
+			expected: "example.com:5000/foo:tag",
+		},
+		{
+			input:    "example.com:5000/foo@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+			port:     "443",
+			expected: "example.com:443/foo@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+		},
+		{
+			input:    "[fc00::1]/foo",
+			port:     "5000",
+			expected: "[fc00::1]:5000/foo",
+		},
+		{
+			input:    "[fc00::1]:5000/foo",
+			port:     "5001",
+			expected: "[fc00::1]:5001/foo",
+		},
+		{
+			input: "example.com/foo",
+			port:  "0",
+			err:   ErrPortInvalid,
+		},
+		{
+			input: "example.com/foo",
+			port:  "65536",
+			err:   ErrPortInvalid,
+		},
+		{
+			input: "example.com/foo",
+			port:  "http",
+			err:   ErrPortInvalid,
+		},
+		{
+			input: "example.com/foo",
+			port:  "+80",
+			err:   ErrPortInvalid,
+		},
+		{
+			input: "example.com/foo",
+			port:  "",
+			err:   ErrPortInvalid,
+		},
+		{
+			input: "example.com/foo",
+			port:  "0080",
+			err:   ErrPortInvalid,
+		},
+		{
+			input: "example.com/" + strings.Repeat("a", NameTotalLengthMax-len("example.com/")),
+			port:  "65535",
+			err:   ErrNameTooLong,
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input+"/"+testcase.port, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := WithPort(ref, testcase.port)
+			if err != testcase.err {
+				t.Fatalf("unexpected error: got %v, expected %v", err, testcase.err)
+			}
+			if err != nil {
+				return
+			}
+			if actual.String() != testcase.expected {
+				t.Errorf("unexpected: got %q, expected %q", actual.String(), testcase.expected)
+			}
+			if port, _ := Port(actual); port != testcase.port {
+				t.Errorf("unexpected port: got %q, expected %q", port, testcase.port)
+			}
+		})
+	}
+
+	named, err := WithName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WithPort(named, "5000"); err != ErrReferenceInvalidFormat {
+		t.Errorf("expected %v for reference without domain, got %v", ErrReferenceInvalidFormat, err)
+	}
+}
+
+func TestRegistryHost(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "nginx", expected: "registry-1.docker.io"},
+		{input: "docker.io/user/app:1.0", expected: "registry-1.docker.io"},
+		{input: "index.docker.io/library/nginx", expected: "registry-1.docker.io"},
+		{input: "registry.example.com/team/app", expected: "registry.example.com"},
+		{input: "localhost:5000/app", expected: "localhost:5000"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := RegistryHost(ref); actual != testcase.expected {
+				t.Errorf("unexpected: got %q, expected %q", actual, testcase.expected)
+			}
+		})
+	}
+
+	// References that were not normalized keep the legacy domain.
+	named, err := WithName("index.docker.io/library/nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := RegistryHost(named); actual != "registry-1.docker.io" {
+		t.Errorf("unexpected: got %q, expected %q", actual, "registry-1.docker.io")
+	}
+}
+
+func TestCanonicalDomain(t *testing.T) {
+	t.Parallel()
+	for domain, expected := range map[string]string{
+		"docker.io":            "docker.io",
+		"index.docker.io":      "docker.io",
+		"registry-1.docker.io": "docker.io",
+		"gcr.io":               "gcr.io",
+		"localhost:5000":       "localhost:5000",
+	} {
+		if actual := CanonicalDomain(domain); actual != expected {
+			t.Errorf("CanonicalDomain(%q): got %q, expected %q", domain, actual, expected)
+		}
+	}
+}
+
+func TestBlockedDomain(t *testing.T) {
+	t.Parallel()
+	blocked := []string{"gcr.io", ".internal", "registry.example.com:5000", "index.docker.io"}
+	testcases := []struct {
+		input string
+		rule  string
+	}{
+		{input: "gcr.io/project/app", rule: "gcr.io"},
+		{input: "GCR.io/project/app", rule: "gcr.io"},
+		{input: "gcr.io:443/project/app", rule: "gcr.io"},
+		{input: "registry.internal/app", rule: ".internal"},
+		{input: "registry.corp.internal:5000/app", rule: ".internal"},
+		{input: "registry.example.com:5000/app", rule: "registry.example.com:5000"},
+		{input: "nginx", rule: "index.docker.io"},
+		{input: "registry.example.com/app"},
+		{input: "registry.example.com:5001/app"},
+		{input: "internal.example.com/app"},
+		{input: "quay.io/org/app"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			isBlocked, rule := BlockedDomain(ref, blocked)
+			if isBlocked != (testcase.rule != "") || rule != testcase.rule {
+				t.Errorf("unexpected: got (%v, %q), expected rule %q", isBlocked, rule, testcase.rule)
+			}
+		})
+	}
+}
+
+func TestDisambiguatePort(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input      string
+		isHostPort bool
+		name       string
+	}{
+		{input: "192.168.0.1:80", isHostPort: false, name: "docker.io/library/192.168.0.1:80"},
+		{input: "192.168.0.1:8/debian", isHostPort: true, name: "192.168.0.1:8/debian"},
+		{input: "192.168.0.1/debian:80", isHostPort: false, name: "192.168.0.1/debian:80"},
+		{input: "repo:tag", isHostPort: false, name: "docker.io/library/repo:tag"},
+		{input: "localhost:5000/repo:tag", isHostPort: true, name: "localhost:5000/repo:tag"},
+		{input: "[fc00::1]:5000/docker", isHostPort: true, name: "[fc00::1]:5000/docker"},
+		{input: "[fc00::1]/docker", isHostPort: false, name: "[fc00::1]/docker"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			isHostPort, err := DisambiguatePort(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isHostPort != testcase.isHostPort {
+				t.Errorf("unexpected result: got %v, expected %v", isHostPort, testcase.isHostPort)
+			}
+
+			// The decision must match how the reference is parsed.
+			named, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if named.String() != testcase.name {
+				t.Errorf("unexpected reference: got %q, expected %q", named.String(), testcase.name)
+			}
+		})
+	}
+}
+
+func TestDisambiguatePortInvalid(t *testing.T) {
+	t.Parallel()
+	for _, input := range []string{"", "192.168.0.1:/debian", "repo:tag:tag"} {
+		if _, err := DisambiguatePort(input); err == nil {
+			t.Errorf("%q: expected error", input)
+		}
+	}
+}
+
+func TestParseWithAliases(t *testing.T) {
+	t.Parallel()
+	aliases := map[string]string{
+		"dh":       "docker.io",
+		"internal": "registry.corp.example.com",
+	}
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "dh/nginx:1.25", expected: "docker.io/library/nginx:1.25"},
+		{input: "dh/user/app", expected: "docker.io/user/app"},
+		{input: "internal/team/app:1.0", expected: "registry.corp.example.com/team/app:1.0"},
+		{input: "example.com/internal/app", expected: "example.com/internal/app"},
+		{input: "user/app", expected: "docker.io/user/app"},
+		{input: "internal", expected: "docker.io/library/internal"},
+		{input: "nginx:1.25", expected: "docker.io/library/nginx:1.25"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			named, err := ParseWithAliases(testcase.input, aliases)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if named.String() != testcase.expected {
+				t.Errorf("unexpected reference: got %q, expected %q", named.String(), testcase.expected)
+			}
+		})
+	}
+	if _, err := ParseWithAliases("internal/", aliases); err == nil {
+		t.Error("expected error for an alias without path")
+	}
+}
@@ -0,0 +1,28 @@
+package reference
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ParseEnv parses the reference in the environment variable key, as done by
+// [ParseNormalizedNamed], after trimming surrounding whitespace. The returned
+// error names the variable if it is unset or empty, or if it does not hold a
+// valid reference; the error of [ParseNormalizedNamed] is wrapped in the
+// latter case.
+func ParseEnv(key string) (Named, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s is not set", key)
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("environment variable %s is empty", key)
+	}
+	named, err := parseNormalized(value)
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w", key, err)
+	}
+	return named, nil
+}
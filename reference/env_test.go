@@ -0,0 +1,39 @@
+package reference
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestParseEnv cannot run in parallel, as it modifies the environment.
+func TestParseEnv(t *testing.T) {
+	const key = "REFERENCE_TEST_IMAGE"
+
+	t.Setenv(key, " nginx:1.25\n")
+	named, err := ParseEnv(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "docker.io/library/nginx:1.25"; named.String() != expected {
+		t.Errorf("unexpected reference: got %q, expected %q", named.String(), expected)
+	}
+
+	t.Setenv(key, "nginx::1.25")
+	_, err = ParseEnv(key)
+	if !errors.Is(err, ErrReferenceInvalidFormat) {
+		t.Errorf("unexpected error: got %v, expected %v", err, ErrReferenceInvalidFormat)
+	}
+	if err != nil && !strings.Contains(err.Error(), key) {
+		t.Errorf("error %q does not name %s", err, key)
+	}
+
+	t.Setenv(key, "  ")
+	if _, err := ParseEnv(key); err == nil || !strings.Contains(err.Error(), key) {
+		t.Errorf("unexpected error for empty variable: %v", err)
+	}
+
+	if _, err := ParseEnv(key + "_UNSET"); err == nil || !strings.Contains(err.Error(), key+"_UNSET") {
+		t.Errorf("unexpected error for unset variable: %v", err)
+	}
+}
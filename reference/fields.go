@@ -0,0 +1,164 @@
+package reference
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// ToFields returns the components of ref as a map with the keys "domain",
+// "path", "tag", and "digest". Components that are not present in ref are
+// omitted. The result can be serialized deterministically (for example, by
+// [encoding/json], which sorts map keys), and converted back to a reference
+// using [FromFields].
+func ToFields(ref Reference) map[string]string {
+	c := componentsOf(ref)
+	fields := make(map[string]string, 4)
+	for key, value := range map[string]string{
+		"domain": c.domain,
+		"path":   c.path,
+		"tag":    c.tag,
+		"digest": c.digest.String(),
+	} {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// FromFields returns the reference described by fields, as returned by
+// [ToFields]. An error is returned if fields contains unknown keys, or if
+// any of the components is invalid.
+func FromFields(fields map[string]string) (Reference, error) {
+	var c components
+	for key, value := range fields {
+		switch key {
+		case "domain":
+			c.domain = value
+		case "path":
+			c.path = value
+		case "tag":
+			c.tag = value
+		case "digest":
+			c.digest = digest.Digest(value)
+		default:
+			return nil, fmt.Errorf("unknown reference field %q", key)
+		}
+	}
+	return fromComponents(c)
+}
+
+// ToProtoFields returns the components of ref as separate strings, for
+// transport in messages with a field per component, such as protocol buffer
+// messages. Components that are not present in ref are empty. This is the
+// serialization boundary for such messages: references should be sent as
+// returned by ToProtoFields, and received using [FromProtoFields], rather
+// than formatted and parsed as strings.
+func ToProtoFields(ref Reference) (domain, path, tag, digest string) {
+	c := componentsOf(ref)
+	return c.domain, c.path, c.tag, c.digest.String()
+}
+
+// FromProtoFields returns the reference described by its components, as
+// returned by [ToProtoFields]. Empty components are treated as not present.
+// An error is returned if any of the components is invalid.
+func FromProtoFields(domain, path, tag, dgst string) (Reference, error) {
+	return fromComponents(components{
+		domain: domain,
+		path:   path,
+		tag:    tag,
+		digest: digest.Digest(dgst),
+	})
+}
+
+// canonicalFields holds the components of a reference in the order in which
+// they are encoded by [CanonicalJSON].
+type canonicalFields struct {
+	Domain string `json:"domain,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// CanonicalJSON returns the components of ref as a JSON object with a fixed
+// key order ("domain", "path", "tag", "digest") and no insignificant
+// whitespace, for example:
+//
+//	{"domain":"docker.io","path":"library/nginx","tag":"1.25"}
+//
+// Components that are not present in ref are omitted. The output only
+// depends on the components of ref, so it is byte-for-byte stable, and can be
+// signed. The object can be decoded with [FromFields].
+func CanonicalJSON(ref Reference) ([]byte, error) {
+	c := componentsOf(ref)
+	return json.Marshal(canonicalFields{
+		Domain: c.domain,
+		Path:   c.path,
+		Tag:    c.tag,
+		Digest: c.digest.String(),
+	})
+}
+
+// fromComponents validates c, and returns the reference it describes.
+func fromComponents(c components) (Reference, error) {
+	if c.digest != "" {
+		if err := c.digest.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if c.domain == "" && c.path == "" && c.tag == "" {
+		if c.digest == "" {
+			return nil, ErrNameEmpty
+		}
+		return digestReference(c.digest), nil
+	}
+
+	named, err := Join(c.domain, c.path)
+	if err != nil {
+		return nil, err
+	}
+	if c.tag != "" {
+		if named, err = WithTag(named, c.tag); err != nil {
+			return nil, err
+		}
+	}
+	if c.digest != "" {
+		if named, err = WithDigest(named, c.digest); err != nil {
+			return nil, err
+		}
+	}
+	return named, nil
+}
+
+// RefStrings holds the string forms of a reference, as returned by
+// [Strings]. Components that are not present in the reference are empty,
+// and omitted when encoded as JSON.
+type RefStrings struct {
+	// Familiar is the familiar form of the reference (see [FamiliarString]).
+	Familiar string `json:"familiar"`
+
+	// Canonical is the fully qualified form of the reference, as returned by
+	// its String method.
+	Canonical string `json:"canonical"`
+
+	Domain string `json:"domain,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// Strings returns the familiar and canonical forms of ref, along with its
+// individual components, for example, to include them in an API response.
+func Strings(ref Reference) RefStrings {
+	c := componentsOf(ref)
+	return RefStrings{
+		Familiar:  FamiliarString(ref),
+		Canonical: ref.String(),
+		Domain:    c.domain,
+		Path:      c.path,
+		Tag:       c.tag,
+		Digest:    c.digest.String(),
+	}
+}
@@ -0,0 +1,258 @@
+package reference
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFieldsRoundTrip(t *testing.T) {
+	t.Parallel()
+	const dgst = "sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+	testcases := []struct {
+		input  string
+		fields map[string]string
+	}{
+		{
+			input:  "docker.io/library/nginx",
+			fields: map[string]string{"domain": "docker.io", "path": "library/nginx"},
+		},
+		{
+			input:  "example.com:5000/team/app:1.25",
+			fields: map[string]string{"domain": "example.com:5000", "path": "team/app", "tag": "1.25"},
+		},
+		{
+			input:  "example.com/app@" + dgst,
+			fields: map[string]string{"domain": "example.com", "path": "app", "digest": dgst},
+		},
+		{
+			input:  "example.com/app:1.25@" + dgst,
+			fields: map[string]string{"domain": "example.com", "path": "app", "tag": "1.25", "digest": dgst},
+		},
+		{
+			input:  "app:1.25",
+			fields: map[string]string{"path": "app", "tag": "1.25"},
+		},
+		{
+			input:  dgst,
+			fields: map[string]string{"digest": dgst},
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			var ref Reference
+			var err error
+			if testcase.fields["path"] == "" {
+				ref, err = ParseAnyReference(testcase.input)
+			} else {
+				ref, err = Parse(testcase.input)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			fields := ToFields(ref)
+			if len(fields) != len(testcase.fields) {
+				t.Fatalf("unexpected fields: got %v, expected %v", fields, testcase.fields)
+			}
+			for key, value := range testcase.fields {
+				if fields[key] != value {
+					t.Errorf("unexpected %s: got %q, expected %q", key, fields[key], value)
+				}
+			}
+
+			roundTrip, err := FromFields(fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalReference(roundTrip, ref) {
+				t.Errorf("unexpected reference %#v, expected %#v", roundTrip, ref)
+			}
+		})
+	}
+}
+
+func TestFromFieldsInvalid(t *testing.T) {
+	t.Parallel()
+	for _, fields := range []map[string]string{
+		{},
+		{"domain": "example.com"},
+		{"path": "app", "registry": "example.com"},
+		{"domain": "-example.com", "path": "app"},
+		{"path": "App"},
+		{"path": "app", "tag": "-tag"},
+		{"path": "app", "digest": "sha256:invalid"},
+		{"tag": "1.25"},
+	} {
+		if ref, err := FromFields(fields); err == nil {
+			t.Errorf("expected error for %v, got %q", fields, ref.String())
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	t.Parallel()
+	const dgst = "sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+	testcases := []struct {
+		input    string
+		expected RefStrings
+		json     string
+	}{
+		{
+			input:    "nginx",
+			expected: RefStrings{Familiar: "nginx", Canonical: "docker.io/library/nginx", Domain: "docker.io", Path: "library/nginx"},
+			json:     `{"familiar":"nginx","canonical":"docker.io/library/nginx","domain":"docker.io","path":"library/nginx"}`,
+		},
+		{
+			input:    "user/app:1.0",
+			expected: RefStrings{Familiar: "user/app:1.0", Canonical: "docker.io/user/app:1.0", Domain: "docker.io", Path: "user/app", Tag: "1.0"},
+		},
+		{
+			input:    "example.com/app@" + dgst,
+			expected: RefStrings{Familiar: "example.com/app@" + dgst, Canonical: "example.com/app@" + dgst, Domain: "example.com", Path: "app", Digest: dgst},
+		},
+		{
+			input:    "nginx:1.25@" + dgst,
+			expected: RefStrings{Familiar: "nginx:1.25@" + dgst, Canonical: "docker.io/library/nginx:1.25@" + dgst, Domain: "docker.io", Path: "library/nginx", Tag: "1.25", Digest: dgst},
+		},
+		{
+			input:    dgst,
+			expected: RefStrings{Familiar: dgst, Canonical: dgst, Digest: dgst},
+			json:     `{"familiar":"` + dgst + `","canonical":"` + dgst + `","digest":"` + dgst + `"}`,
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseAnyReference(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual := Strings(ref)
+			if actual != testcase.expected {
+				t.Errorf("unexpected strings:\ngot      %+v\nexpected %+v", actual, testcase.expected)
+			}
+			if testcase.json != "" {
+				b, err := json.Marshal(actual)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(b) != testcase.json {
+					t.Errorf("unexpected JSON: got %s, expected %s", b, testcase.json)
+				}
+			}
+		})
+	}
+}
+
+func TestProtoFieldsRoundTrip(t *testing.T) {
+	t.Parallel()
+	const dgst = "sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+	for _, input := range []string{
+		"docker.io/library/nginx",
+		"example.com:5000/team/app:1.25",
+		"example.com/app@" + dgst,
+		"example.com/app:1.25@" + dgst,
+		"[2001:db8::1]:5000/app",
+		"app:1.25",
+		dgst,
+	} {
+		input := input
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+			var ref Reference
+			var err error
+			if strings.HasPrefix(input, "sha256:") {
+				ref, err = ParseAnyReference(input)
+			} else {
+				ref, err = Parse(input)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			domain, path, tag, digest := ToProtoFields(ref)
+			fields := ToFields(ref)
+			if domain != fields["domain"] || path != fields["path"] || tag != fields["tag"] || digest != fields["digest"] {
+				t.Errorf("unexpected fields: got (%q, %q, %q, %q), expected %v", domain, path, tag, digest, fields)
+			}
+			roundTrip, err := FromProtoFields(domain, path, tag, digest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalReference(roundTrip, ref) {
+				t.Errorf("unexpected reference %#v, expected %#v", roundTrip, ref)
+			}
+		})
+	}
+	for _, fields := range [][4]string{
+		{},
+		{"example.com", "", "", ""},
+		{"", "App", "", ""},
+		{"", "app", "-tag", ""},
+		{"", "app", "", "sha256:abc"},
+	} {
+		if ref, err := FromProtoFields(fields[0], fields[1], fields[2], fields[3]); err == nil {
+			t.Errorf("%q: expected error, got %q", fields, ref.String())
+		}
+	}
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	t.Parallel()
+	const dgst = "sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "nginx",
+			expected: `{"domain":"docker.io","path":"library/nginx"}`,
+		},
+		{
+			input:    "example.com:5000/team/app:1.25@" + dgst,
+			expected: `{"domain":"example.com:5000","path":"team/app","tag":"1.25","digest":"` + dgst + `"}`,
+		},
+		{
+			input:    "example.com/app@" + dgst,
+			expected: `{"domain":"example.com","path":"app","digest":"` + dgst + `"}`,
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			ref := mustParseNormalized(t, testcase.input)
+			for i := 0; i < 10; i++ {
+				out, err := CanonicalJSON(ref)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(out) != testcase.expected {
+					t.Fatalf("unexpected output: got %s, expected %s", out, testcase.expected)
+				}
+			}
+
+			var fields map[string]string
+			if err := json.Unmarshal([]byte(testcase.expected), &fields); err != nil {
+				t.Fatal(err)
+			}
+			roundTrip, err := FromFields(fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalReference(roundTrip, ref) {
+				t.Errorf("unexpected reference %#v, expected %#v", roundTrip, ref)
+			}
+		})
+	}
+
+	out, err := CanonicalJSON(digestReference(dgst))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"digest":"` + dgst + `"}`; string(out) != expected {
+		t.Errorf("unexpected output: got %s, expected %s", out, expected)
+	}
+}
@@ -0,0 +1,129 @@
+package reference
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NameGrammarDescription returns a human-readable description of the
+// characters allowed in the remote-name of a repository (the path, without
+// domain), for use in validation messages. The description is derived from
+// the regular expressions of the reference grammar, so it does not drift
+// from the characters that are actually accepted.
+func NameGrammarDescription() string {
+	alnum := regexp.MustCompile(anchored(alphanumeric))
+	component := regexp.MustCompile(anchored(pathComponent))
+	return fmt.Sprintf("%s, optionally separated by %s, in one or more components separated by \"/\"; at most %d characters",
+		joinWords(describeChars(alnum.MatchString), "and"),
+		joinWords(describeSeparators(component), "or"),
+		NameTotalLengthMax)
+}
+
+// TagGrammarDescription returns a human-readable description of the
+// characters allowed in a tag, for use in validation messages. As with
+// [NameGrammarDescription], the description is derived from the regular
+// expressions of the reference grammar.
+func TagGrammarDescription() string {
+	first := describeChars(anchoredTagRegexp.MatchString)
+	all := describeChars(func(c string) bool { return anchoredTagRegexp.MatchString("a" + c) })
+	var notFirst []string
+	for _, c := range all {
+		if !containsString(first, c) {
+			notFirst = append(notFirst, c)
+		}
+	}
+	maxLen := 0
+	for n := 1; n <= 1024 && anchoredTagRegexp.MatchString(strings.Repeat("a", n)); n++ {
+		maxLen = n
+	}
+	description := fmt.Sprintf("up to %d %s", maxLen, joinWords(all, "and"))
+	if len(notFirst) > 0 {
+		description += ", not starting with " + joinWords(notFirst, "or")
+	}
+	return description
+}
+
+// describeChars returns the printable ASCII characters for which match
+// returns true, with complete classes of letters and digits described by
+// name (such as "lowercase letters"), followed by other characters quoted.
+func describeChars(match func(c string) bool) []string {
+	var words []string
+	for _, class := range []struct {
+		name     string
+		from, to byte
+	}{
+		{name: "lowercase letters", from: 'a', to: 'z'},
+		{name: "uppercase letters", from: 'A', to: 'Z'},
+		{name: "digits", from: '0', to: '9'},
+	} {
+		all := true
+		for c := class.from; c <= class.to; c++ {
+			all = all && match(string(c))
+		}
+		if all {
+			words = append(words, class.name)
+		}
+	}
+	for c := byte('!'); c <= '~'; c++ {
+		if isAlphanumeric(c) || !match(string(c)) {
+			continue
+		}
+		words = append(words, strconv.Quote(string(c)))
+	}
+	return words
+}
+
+// describeSeparators returns the separators allowed between alphanumeric
+// characters of a path-component, as matched by component.
+func describeSeparators(component *regexp.Regexp) []string {
+	var words []string
+	for c := byte('!'); c <= '~'; c++ {
+		if isAlphanumeric(c) {
+			continue
+		}
+		var counts []int
+		for n := 1; n <= 3; n++ {
+			if component.MatchString("a" + strings.Repeat(string(c), n) + "a") {
+				counts = append(counts, n)
+			}
+		}
+		if len(counts) == 3 {
+			words = append(words, "one or more "+strconv.Quote(string(c)))
+			continue
+		}
+		for _, n := range counts {
+			words = append(words, strconv.Quote(strings.Repeat(string(c), n)))
+		}
+	}
+	return words
+}
+
+func isAlphanumeric(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// joinWords joins words as in an English list, using conjunction before the
+// last word, for example, "a, b, and c".
+func joinWords(words []string, conjunction string) string {
+	switch len(words) {
+	case 0:
+		return ""
+	case 1:
+		return words[0]
+	case 2:
+		return words[0] + " " + conjunction + " " + words[1]
+	default:
+		return strings.Join(words[:len(words)-1], ", ") + ", " + conjunction + " " + words[len(words)-1]
+	}
+}
@@ -0,0 +1,19 @@
+package reference
+
+import "testing"
+
+func TestGrammarDescription(t *testing.T) {
+	t.Parallel()
+	// These are change-detector tests: if the reference grammar changes,
+	// the descriptions change as well, and must be reviewed.
+	const (
+		expectedName = `lowercase letters and digits, optionally separated by one or more "-", ".", "_", or "__", in one or more components separated by "/"; at most 255 characters`
+		expectedTag  = `up to 128 lowercase letters, uppercase letters, digits, "-", ".", and "_", not starting with "-" or "."`
+	)
+	if actual := NameGrammarDescription(); actual != expectedName {
+		t.Errorf("unexpected name description:\ngot      %s\nexpected %s", actual, expectedName)
+	}
+	if actual := TagGrammarDescription(); actual != expectedTag {
+		t.Errorf("unexpected tag description:\ngot      %s\nexpected %s", actual, expectedTag)
+	}
+}
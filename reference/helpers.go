@@ -1,6 +1,10 @@
 package reference
 
-import "path"
+import (
+	"hash/fnv"
+	"path"
+	"strings"
+)
 
 // IsNameOnly returns true if reference only contains a repo name.
 func IsNameOnly(ref Named) bool {
@@ -22,6 +26,23 @@ func FamiliarName(ref Named) string {
 	return ref.Name()
 }
 
+// PathFamiliar returns the path of ref in its familiar form: for official
+// images on Docker Hub, the "library/" prefix is removed, so that it returns
+// "foo" for "docker.io/library/foo". Unlike [FamiliarName], the domain is
+// never included; and unlike [Path], which returns "library/foo", the
+// "library/" prefix is only kept for other domains (such as
+// "gcr.io/library/foo") and nested repositories.
+func PathFamiliar(ref Named) string {
+	path := Path(ref)
+	if CanonicalDomain(Domain(ref)) != defaultDomain {
+		return path
+	}
+	if remainder := strings.TrimPrefix(path, officialRepoPrefix); !strings.ContainsRune(remainder, '/') {
+		return remainder
+	}
+	return path
+}
+
 // FamiliarString returns the familiar string representation
 // for the given reference, familiarizing if needed.
 func FamiliarString(ref Reference) string {
@@ -31,6 +52,39 @@ func FamiliarString(ref Reference) string {
 	return ref.String()
 }
 
+// FamiliarIsLossless reports whether the familiar string of ref (see
+// [FamiliarString]) is parsed back into the same reference by
+// [ParseNormalizedNamed], so that it can be used in place of the full string
+// of ref. This is the case for references obtained from
+// [ParseNormalizedNamed], but not for all references: for example, the
+// familiar string of "docker.io/nginx" as returned by [Parse] is "nginx",
+// which is normalized to "docker.io/library/nginx", and a repository named
+// with 64 hexadecimal characters has a familiar string that is rejected as
+// ambiguous with an image ID. References without a name, such as a digest,
+// are always represented by their full string, so true is returned for them.
+func FamiliarIsLossless(ref Reference) bool {
+	if _, ok := ref.(Named); !ok {
+		return true
+	}
+	named, err := parseNormalized(FamiliarString(ref))
+	return err == nil && named.String() == ref.String()
+}
+
+// RefSuffix returns the tag and digest of ref as they appear at the end of
+// its string representation: ":<tag>", "@<digest>", or ":<tag>@<digest>",
+// or an empty string if ref has neither, for example, for display next to
+// the name of the repository.
+func RefSuffix(ref Reference) string {
+	var suffix string
+	if tagged, ok := ref.(Tagged); ok {
+		suffix = ":" + tagged.Tag()
+	}
+	if digested, ok := ref.(Digested); ok {
+		suffix += "@" + digested.Digest().String()
+	}
+	return suffix
+}
+
 // FamiliarMatch reports whether ref matches the specified pattern.
 // See [path.Match] for supported patterns.
 func FamiliarMatch(pattern string, ref Reference) (bool, error) {
@@ -40,3 +94,226 @@ func FamiliarMatch(pattern string, ref Reference) (bool, error) {
 	}
 	return matched, err
 }
+
+// MinimalDistinct returns string representations of a and b that are as short
+// as possible while still telling them apart. If a and b have the same domain,
+// the domain is omitted, as is the "library/" prefix of official images on
+// Docker Hub (see [PathFamiliar]); for example, "example.com/team/app:1" and
+// "example.com/team/app:2" are shortened to "team/app:1" and "team/app:2".
+// Otherwise, the familiar strings are used, which only omit the domain for
+// Docker Hub. If the shortened strings are identical but the references are
+// not, the full strings are returned.
+func MinimalDistinct(a, b Named) (string, string) {
+	var sa, sb string
+	if Domain(a) == Domain(b) {
+		sa, sb = PathFamiliar(a)+RefSuffix(a), PathFamiliar(b)+RefSuffix(b)
+	} else {
+		sa, sb = FamiliarString(a), FamiliarString(b)
+	}
+	if sa == sb {
+		if fa, fb := a.String(), b.String(); fa != fb {
+			return fa, fb
+		}
+	}
+	return sa, sb
+}
+
+// Ancestors returns the repositories that are ancestors of ref, from the
+// nearest to the farthest. For example, the ancestors of "docker.io/a/b/c"
+// are "docker.io/a/b" and "docker.io/a". The tag and digest of ref are not
+// included. No ancestors are returned if the path of ref has a single
+// component.
+func Ancestors(ref Named) []Named {
+	domain, path := Domain(ref), Path(ref)
+	var ancestors []Named
+	for i := strings.LastIndexByte(path, '/'); i > 0; i = strings.LastIndexByte(path, '/') {
+		path = path[:i]
+		ancestors = append(ancestors, repository{domain: domain, path: path})
+	}
+	return ancestors
+}
+
+// Truncate returns the familiar string of ref, truncated to at most max runes
+// for display, for example in log lines. Long strings are shortened in the
+// middle, replacing the removed part with an ellipsis ("…"), while keeping
+// the domain at the start and the tag and digest at the end whenever they
+// fit. As the ellipsis is not allowed in references, a truncated string can
+// never be mistaken for a valid reference.
+func Truncate(ref Reference, max int) string {
+	const ellipsis = '…'
+	s := []rune(FamiliarString(ref))
+	if len(s) <= max {
+		return string(s)
+	}
+	if max < 1 {
+		return ""
+	}
+
+	var prefix, suffix int
+	if named, ok := ref.(Named); ok {
+		familiarName := FamiliarName(named)
+		suffix = len(s) - len([]rune(familiarName))
+		if domain := Domain(named); domain != "" && strings.HasPrefix(familiarName, domain+"/") {
+			prefix = len([]rune(domain)) + 1
+		}
+	}
+
+	var head, tail int
+	if prefix+suffix+1 <= max {
+		head, tail = max-1-suffix, suffix
+	} else {
+		head = max / 2
+		tail = max - 1 - head
+	}
+	return string(s[:head]) + string(ellipsis) + string(s[len(s)-tail:])
+}
+
+// ShellQuote returns the familiar string of ref quoted for safe inclusion as
+// a single word in a POSIX shell command. The string is wrapped in single
+// quotes, and any single quotes in it are escaped. While the reference
+// grammar does not allow characters that are special to the shell, ref may be
+// a Reference implementation that does not enforce the grammar.
+func ShellQuote(ref Reference) string {
+	return "'" + strings.ReplaceAll(FamiliarString(ref), "'", `'\''`) + "'"
+}
+
+// RepositoryScope returns the scope for ref used by registry token
+// authentication, in the form "repository:<path>:<actions>", where path is
+// the remote-name of ref (without domain) and actions are joined by commas.
+// The "pull" action is used if no actions are given. For example, the scope
+// for "nginx" with the actions "pull" and "push" is
+// "repository:library/nginx:pull,push".
+func RepositoryScope(ref Named, actions ...string) string {
+	if len(actions) == 0 {
+		actions = []string{"pull"}
+	}
+	return "repository:" + Path(ref) + ":" + strings.Join(actions, ",")
+}
+
+// UIPath returns a URL path for ref in the style of the Docker Hub web UI:
+// "/_/<name>" for official images (for example, "/_/nginx" for
+// "docker.io/library/nginx"), and "/r/<path>" for other Docker Hub
+// repositories (for example, "/r/user/app"). As such paths are not defined
+// for other registries, they fall back to "/" followed by the full name (for
+// example, "/gcr.io/project/app"). The tag and digest of ref are ignored.
+func UIPath(ref Named) string {
+	if CanonicalDomain(Domain(ref)) != defaultDomain {
+		return "/" + ref.Name()
+	}
+	path := strings.TrimPrefix(Path(ref), officialRepoPrefix)
+	if !strings.ContainsRune(path, '/') {
+		return "/_/" + path
+	}
+	return "/r/" + Path(ref)
+}
+
+// RepositoryColorHash returns a 32-bit hash of the repository of ref, for
+// example, to derive a stable color or icon for it in a user interface. The
+// hash is the 32-bit FNV-1a hash of the [RepositoryKey] of ref, so it is the
+// same for all spellings of a repository, and does not change between
+// processes or releases. The tag and digest of ref are ignored.
+func RepositoryColorHash(ref Named) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(RepositoryKey(ref)))
+	return h.Sum32()
+}
+
+// Relative returns the string representation of ref relative to the registry
+// with domain base, for display in a view scoped to that registry. If ref is
+// on base, the domain is omitted, as in "team/app:1.0" for
+// "registry.example.com/team/app:1.0"; on Docker Hub, the "library/" prefix
+// of official images is omitted as well, as in the familiar form. Otherwise,
+// the full string is returned. Domains are compared in their canonical form
+// (see [CanonicalDomain]).
+func Relative(base string, ref Named) string {
+	domain := CanonicalDomain(Domain(ref))
+	if domain != CanonicalDomain(base) {
+		return ref.String()
+	}
+	repo := repository{path: Path(ref)}
+	if domain == defaultDomain {
+		repo.path = familiarizeName(repository{domain: defaultDomain, path: repo.path}).path
+	}
+	return withRepository(ref, repo).String()
+}
+
+// Namespace returns the first segment of the path of ref, which is the
+// namespace (such as the user or organization), and true if the path has more
+// than one segment. For example, it returns "library" for
+// "docker.io/library/nginx", and "user" for "docker.io/user/app". False is
+// returned for single-segment paths, such as "example.com/app".
+func Namespace(ref Named) (string, bool) {
+	namespace, _, ok := strings.Cut(Path(ref), "/")
+	if !ok {
+		return "", false
+	}
+	return namespace, true
+}
+
+// InNamespace reports whether the path of ref starts with the namespace
+// component namespace, such as "acme" for "docker.io/acme/app". The
+// repository is normalized as for [Equal] before it is checked, so that
+// official images on Docker Hub (such as "nginx") are in the "library"
+// namespace, and references with a single path component (such as
+// "example.com/app") are in no namespace.
+func InNamespace(ref Named, namespace string) bool {
+	_, path := canonicalRepository(Domain(ref), Path(ref))
+	first, _, ok := strings.Cut(path, "/")
+	return ok && first == namespace
+}
+
+// Merge applies override to base, as done when layering configuration.
+// override is one of:
+//
+//   - a tag, prefixed with ":" (for example, ":1.26"), which replaces the
+//     tag of base, and removes its digest, as the digest pinned the previous
+//     tag
+//   - a digest, prefixed with "@" (for example, "@sha256:<hex>"), which pins
+//     base to the digest, preserving its tag
+//   - a tag and digest (for example, ":1.26@sha256:<hex>"), which replace
+//     both
+//   - a full reference (in familiar or fully qualified form), which replaces
+//     base entirely
+func Merge(base Named, override string) (Named, error) {
+	if !strings.HasPrefix(override, ":") && !strings.HasPrefix(override, "@") {
+		return parseNormalized(override)
+	}
+	ref, err := Parse(base.Name() + override)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(override, "@") {
+		return WithDigest(base, ref.(Digested).Digest())
+	}
+	return ref.(Named), nil
+}
+
+// CommonRepositoryPrefix returns the longest prefix of whole path components
+// shared by the repository names of refs, including the domain; for
+// example, "gcr.io/project" for "gcr.io/project/app" and
+// "gcr.io/project/tools/cli". An empty string is returned if refs is empty
+// or the domains of the references differ. The tags and digests of refs are
+// ignored, and the names are compared as-is, so refs should be normalized
+// (see [ParseNormalizedNamed]).
+func CommonRepositoryPrefix(refs []Named) string {
+	if len(refs) == 0 {
+		return ""
+	}
+	domain := Domain(refs[0])
+	prefix := strings.Split(Path(refs[0]), "/")
+	for _, ref := range refs[1:] {
+		if Domain(ref) != domain {
+			return ""
+		}
+		components := strings.Split(Path(ref), "/")
+		n := 0
+		for n < len(prefix) && n < len(components) && prefix[n] == components[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	if len(prefix) == 0 {
+		return domain
+	}
+	return domain + "/" + strings.Join(prefix, "/")
+}
@@ -0,0 +1,664 @@
+package reference
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func TestMinimalDistinct(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name      string
+		a, b      string
+		expectedA string
+		expectedB string
+	}{
+		{
+			name:      "same domain different tag",
+			a:         "docker.io/library/nginx:1.24",
+			b:         "docker.io/library/nginx:1.25",
+			expectedA: "nginx:1.24",
+			expectedB: "nginx:1.25",
+		},
+		{
+			name:      "different domain",
+			a:         "docker.io/library/nginx:1.25",
+			b:         "gcr.io/library/nginx:1.25",
+			expectedA: "nginx:1.25",
+			expectedB: "gcr.io/library/nginx:1.25",
+		},
+		{
+			name:      "different path",
+			a:         "example.com/team/app",
+			b:         "example.com/team/api",
+			expectedA: "team/app",
+			expectedB: "team/api",
+		},
+		{
+			name:      "same domain different tag on other registry",
+			a:         "example.com/team/app:1",
+			b:         "example.com/team/app:2",
+			expectedA: "team/app:1",
+			expectedB: "team/app:2",
+		},
+		{
+			name:      "different domain same path",
+			a:         "example.com/team/app",
+			b:         "example.com:5000/team/app",
+			expectedA: "example.com/team/app",
+			expectedB: "example.com:5000/team/app",
+		},
+		{
+			name:      "equal",
+			a:         "docker.io/user/app:latest",
+			b:         "docker.io/user/app:latest",
+			expectedA: "user/app:latest",
+			expectedB: "user/app:latest",
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.name, func(t *testing.T) {
+			t.Parallel()
+			a, err := ParseNormalizedNamed(testcase.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ParseNormalizedNamed(testcase.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actualA, actualB := MinimalDistinct(a, b)
+			if actualA != testcase.expectedA || actualB != testcase.expectedB {
+				t.Errorf("unexpected: got (%q, %q), expected (%q, %q)", actualA, actualB, testcase.expectedA, testcase.expectedB)
+			}
+		})
+	}
+}
+
+func TestMinimalDistinctSameFamiliarString(t *testing.T) {
+	t.Parallel()
+	// A non-normalized name familiarizes to the same string as its
+	// normalized counterpart, so the full strings must be used.
+	a, err := WithName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ParseNormalizedNamed("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actualA, actualB := MinimalDistinct(a, b)
+	if actualA != "foo" || actualB != "docker.io/library/foo" {
+		t.Errorf("unexpected: got (%q, %q)", actualA, actualB)
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		expected []string
+	}{
+		{
+			input:    "docker.io/a/b/c:tag",
+			expected: []string{"docker.io/a/b", "docker.io/a"},
+		},
+		{
+			input:    "example.com:5000/a/b/c/d@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+			expected: []string{"example.com:5000/a/b/c", "example.com:5000/a/b", "example.com:5000/a"},
+		},
+		{
+			input:    "user/app",
+			expected: []string{"docker.io/user"},
+		},
+		{
+			input:    "nginx",
+			expected: []string{"docker.io/library"},
+		},
+		{
+			input: "example.com/app",
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ancestors := Ancestors(ref)
+			if len(ancestors) != len(testcase.expected) {
+				t.Fatalf("unexpected ancestors: got %v, expected %v", ancestors, testcase.expected)
+			}
+			for i, ancestor := range ancestors {
+				if ancestor.String() != testcase.expected[i] {
+					t.Errorf("unexpected ancestor %d: got %q, expected %q", i, ancestor.String(), testcase.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+	const dgst = "sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+	testcases := []struct {
+		input    string
+		max      int
+		expected string
+	}{
+		{
+			input:    "registry.example.com/team/project/app:1.25",
+			max:      100,
+			expected: "registry.example.com/team/project/app:1.25",
+		},
+		{
+			input:    "registry.example.com/team/project/app:1.25",
+			max:      42,
+			expected: "registry.example.com/team/project/app:1.25",
+		},
+		{
+			input:    "registry.example.com/team/project/app:1.25",
+			max:      35,
+			expected: "registry.example.com/team/pro…:1.25",
+		},
+		{
+			input:    "registry.example.com/team/project/app:1.25",
+			max:      27,
+			expected: "registry.example.com/…:1.25",
+		},
+		{
+			input:    "registry.example.com/team/project/app:1.25",
+			max:      20,
+			expected: "registry.e…/app:1.25",
+		},
+		{
+			input:    "team/project/application:1.25",
+			max:      20,
+			expected: "team/project/a…:1.25",
+		},
+		{
+			input:    "example.com/app@" + dgst,
+			max:      30,
+			expected: "example.com/app…45843f69fe4aaa",
+		},
+		{
+			input:    "example.com/app:1.25",
+			max:      1,
+			expected: "…",
+		},
+		{
+			input:    "example.com/app:1.25",
+			max:      0,
+			expected: "",
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input+"/"+strconv.Itoa(testcase.max), func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual := Truncate(ref, testcase.max)
+			if actual != testcase.expected {
+				t.Errorf("unexpected: got %q, expected %q", actual, testcase.expected)
+			}
+			if n := utf8.RuneCountInString(actual); n > testcase.max {
+				t.Errorf("result has %d runes, expected at most %d", n, testcase.max)
+			}
+			if actual != FamiliarString(ref) {
+				if _, err := ParseNormalizedNamed(actual); err == nil {
+					t.Errorf("truncated string %q must not be a valid reference", actual)
+				}
+			}
+		})
+	}
+}
+
+// rawReference is a Reference that does not enforce the reference grammar.
+type rawReference string
+
+func (r rawReference) String() string {
+	return string(r)
+}
+
+func TestShellQuote(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		ref      Reference
+		expected string
+	}{
+		{
+			ref:      repository{domain: "docker.io", path: "library/nginx"},
+			expected: `'nginx'`,
+		},
+		{
+			ref:      taggedReference{namedRepository: repository{domain: "example.com:5000", path: "team/app"}, tag: "1.25"},
+			expected: `'example.com:5000/team/app:1.25'`,
+		},
+		{
+			ref:      rawReference(`app:$(rm -rf /)`),
+			expected: `'app:$(rm -rf /)'`,
+		},
+		{
+			ref:      rawReference(`app:it's`),
+			expected: `'app:it'\''s'`,
+		},
+		{
+			ref:      rawReference(`''`),
+			expected: `''\'''\'''`,
+		},
+	}
+	for _, testcase := range testcases {
+		actual := ShellQuote(testcase.ref)
+		if actual != testcase.expected {
+			t.Errorf("unexpected: got %s, expected %s", actual, testcase.expected)
+		}
+		if again := ShellQuote(testcase.ref); again != actual {
+			t.Errorf("quoting is not stable: got %s, then %s", actual, again)
+		}
+		if unquoted := shellUnquote(actual); unquoted != FamiliarString(testcase.ref) {
+			t.Errorf("unquoting %s: got %q, expected %q", actual, unquoted, FamiliarString(testcase.ref))
+		}
+	}
+}
+
+// shellUnquote reverses quoting with single quotes and escaped single quotes,
+// as a POSIX shell would.
+func shellUnquote(s string) string {
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\'':
+			quoted = !quoted
+		case s[i] == '\\' && !quoted && i+1 < len(s):
+			i++
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func TestRepositoryScope(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		actions  []string
+		expected string
+	}{
+		{input: "nginx", expected: "repository:library/nginx:pull"},
+		{input: "nginx:1.25", actions: []string{"pull"}, expected: "repository:library/nginx:pull"},
+		{input: "user/app", actions: []string{"pull", "push"}, expected: "repository:user/app:pull,push"},
+		{input: "registry.example.com:5000/team/app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", actions: []string{"pull", "push", "delete"}, expected: "repository:team/app:pull,push,delete"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			named, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if scope := RepositoryScope(named, testcase.actions...); scope != testcase.expected {
+				t.Errorf("unexpected scope: got %q, expected %q", scope, testcase.expected)
+			}
+		})
+	}
+}
+
+func TestUIPath(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    Named
+		expected string
+	}{
+		{input: repository{domain: "docker.io", path: "library/nginx"}, expected: "/_/nginx"},
+		{input: reference{namedRepository: repository{domain: "docker.io", path: "library/nginx"}, tag: "1.25"}, expected: "/_/nginx"},
+		{input: repository{domain: "index.docker.io", path: "nginx"}, expected: "/_/nginx"},
+		{input: repository{domain: "docker.io", path: "user/app"}, expected: "/r/user/app"},
+		{input: repository{domain: "docker.io", path: "library/distros/ubuntu"}, expected: "/r/library/distros/ubuntu"},
+		{input: repository{domain: "gcr.io", path: "project/app"}, expected: "/gcr.io/project/app"},
+		{input: repository{domain: "localhost:5000", path: "nginx"}, expected: "/localhost:5000/nginx"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input.String(), func(t *testing.T) {
+			t.Parallel()
+			if actual := UIPath(testcase.input); actual != testcase.expected {
+				t.Errorf("unexpected path: got %q, expected %q", actual, testcase.expected)
+			}
+		})
+	}
+}
+
+func TestRelative(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		base     string
+		input    string
+		expected string
+	}{
+		{base: "registry.example.com", input: "registry.example.com/team/app:1.0", expected: "team/app:1.0"},
+		{base: "registry.example.com", input: "registry.example.com/app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", expected: "app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{base: "registry.example.com", input: "other.example.com/team/app:1.0", expected: "other.example.com/team/app:1.0"},
+		{base: "registry.example.com", input: "registry.example.com:5000/app", expected: "registry.example.com:5000/app"},
+		{base: "docker.io", input: "nginx:1.25", expected: "nginx:1.25"},
+		{base: "index.docker.io", input: "docker.io/user/app", expected: "user/app"},
+		{base: "docker.io", input: "docker.io/library/foo/bar", expected: "library/foo/bar"},
+		{base: "docker.io", input: "registry.example.com/nginx", expected: "registry.example.com/nginx"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.base+" "+testcase.input, func(t *testing.T) {
+			t.Parallel()
+			named, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := Relative(testcase.base, named); actual != testcase.expected {
+				t.Errorf("unexpected string: got %q, expected %q", actual, testcase.expected)
+			}
+		})
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input     string
+		namespace string
+	}{
+		{input: "nginx", namespace: "library"},
+		{input: "docker.io/library/nginx:1.25", namespace: "library"},
+		{input: "user/app", namespace: "user"},
+		{input: "example.com/org/team/project/app", namespace: "org"},
+		{input: "example.com/app"},
+		{input: "localhost:5000/app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			named, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			namespace, ok := Namespace(named)
+			if namespace != testcase.namespace || ok != (testcase.namespace != "") {
+				t.Errorf("unexpected result: got (%q, %v), expected %q", namespace, ok, testcase.namespace)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+	const (
+		dgst1 = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		dgst2 = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	)
+	testcases := []struct {
+		base     string
+		override string
+		expected string
+		err      bool
+	}{
+		{base: "nginx:1.25", override: ":1.26", expected: "docker.io/library/nginx:1.26"},
+		{base: "nginx", override: ":1.26", expected: "docker.io/library/nginx:1.26"},
+		{base: "nginx:1.25@" + dgst1, override: ":1.26", expected: "docker.io/library/nginx:1.26"},
+		{base: "nginx:1.25", override: "@" + dgst1, expected: "docker.io/library/nginx:1.25@" + dgst1},
+		{base: "nginx:1.25@" + dgst1, override: "@" + dgst2, expected: "docker.io/library/nginx:1.25@" + dgst2},
+		{base: "example.com/app", override: "@" + dgst1, expected: "example.com/app@" + dgst1},
+		{base: "nginx:1.25", override: ":1.26@" + dgst2, expected: "docker.io/library/nginx:1.26@" + dgst2},
+		{base: "nginx:1.25", override: "example.com/nginx:2.0", expected: "example.com/nginx:2.0"},
+		{base: "nginx:1.25", override: "redis", expected: "docker.io/library/redis"},
+		{base: "nginx:1.25", override: ":", err: true},
+		{base: "nginx:1.25", override: "@sha256:abc", err: true},
+		{base: "nginx:1.25", override: ":-invalid", err: true},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.base+" "+testcase.override, func(t *testing.T) {
+			t.Parallel()
+			base, err := ParseNormalizedNamed(testcase.base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			merged, err := Merge(base, testcase.override)
+			if testcase.err {
+				if err == nil {
+					t.Fatalf("expected error, got %q", merged.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if merged.String() != testcase.expected {
+				t.Errorf("unexpected reference: got %q, expected %q", merged.String(), testcase.expected)
+			}
+		})
+	}
+}
+
+func TestCommonRepositoryPrefix(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name     string
+		inputs   []string
+		expected string
+	}{
+		{
+			name:     "shared prefix",
+			inputs:   []string{"gcr.io/project/app:1.0", "gcr.io/project/tools/cli", "gcr.io/project/tools/lint"},
+			expected: "gcr.io/project",
+		},
+		{
+			name:     "shared component prefix only",
+			inputs:   []string{"gcr.io/project/app", "gcr.io/project-2/app"},
+			expected: "gcr.io",
+		},
+		{
+			name:     "hub",
+			inputs:   []string{"nginx", "redis:7"},
+			expected: "docker.io/library",
+		},
+		{
+			name:   "different domains",
+			inputs: []string{"gcr.io/project/app", "quay.io/project/app"},
+		},
+		{
+			name:     "single",
+			inputs:   []string{"gcr.io/project/app:1.0"},
+			expected: "gcr.io/project/app",
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.name, func(t *testing.T) {
+			t.Parallel()
+			var refs []Named
+			for _, input := range testcase.inputs {
+				refs = append(refs, mustParseNormalized(t, input))
+			}
+			if prefix := CommonRepositoryPrefix(refs); prefix != testcase.expected {
+				t.Errorf("unexpected prefix: got %q, expected %q", prefix, testcase.expected)
+			}
+		})
+	}
+}
+
+func TestFamiliarIsLossless(t *testing.T) {
+	t.Parallel()
+	hexName := "docker.io/library/" + strings.Repeat("f", 64)
+	testcases := []struct {
+		name     string
+		ref      Reference
+		expected bool
+	}{
+		{name: "name only", ref: mustParseNormalized(t, "nginx"), expected: true},
+		{name: "tagged", ref: mustParseNormalized(t, "nginx:1.25"), expected: true},
+		{name: "tagged and digested", ref: mustParseNormalized(t, "docker.io/user/app:1.0@"+digest.FromString("app").String()), expected: true},
+		{name: "other domain", ref: mustParseNormalized(t, "example.com/app:1.0"), expected: true},
+		{name: "digest", ref: digestReference(digest.FromString("app")), expected: true},
+		{name: "missing library prefix", ref: mustParseNamed(t, "docker.io/nginx:1.25"), expected: false},
+		{name: "legacy domain", ref: mustParseNamed(t, "index.docker.io/library/nginx"), expected: false},
+		{name: "missing domain", ref: mustParseNamed(t, "nginx"), expected: false},
+		{name: "hexadecimal name", ref: mustParseNamed(t, hexName), expected: false},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.name, func(t *testing.T) {
+			t.Parallel()
+			if lossless := FamiliarIsLossless(testcase.ref); lossless != testcase.expected {
+				t.Errorf("unexpected result for %q (familiar %q): got %v, expected %v", testcase.ref.String(), FamiliarString(testcase.ref), lossless, testcase.expected)
+			}
+		})
+	}
+}
+
+// mustParseNamed parses s with [Parse], without normalizing it.
+func mustParseNamed(t *testing.T, s string) Named {
+	t.Helper()
+	ref, err := Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	named, ok := ref.(Named)
+	if !ok {
+		t.Fatalf("%q is not a named reference", s)
+	}
+	return named
+}
+
+func TestPathFamiliar(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "docker.io/library/foo", expected: "foo"},
+		{input: "foo:1.0", expected: "foo"},
+		{input: "index.docker.io/library/foo", expected: "foo"},
+		{input: "docker.io/user/app", expected: "user/app"},
+		{input: "docker.io/library/distros/ubuntu", expected: "library/distros/ubuntu"},
+		{input: "gcr.io/library/foo", expected: "library/foo"},
+		{input: "example.com/app", expected: "app"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			named := mustParseNormalized(t, testcase.input)
+			if path := PathFamiliar(named); path != testcase.expected {
+				t.Errorf("unexpected path: got %q, expected %q", path, testcase.expected)
+			}
+		})
+	}
+}
+
+func TestInNamespace(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input     string
+		namespace string
+		expected  bool
+	}{
+		{input: "acme/app", namespace: "acme", expected: true},
+		{input: "docker.io/acme/app:1.0", namespace: "acme", expected: true},
+		{input: "example.com/acme/team/app", namespace: "acme", expected: true},
+		{input: "example.com/team/acme/app", namespace: "acme", expected: false},
+		{input: "acme-corp/app", namespace: "acme", expected: false},
+		{input: "example.com/acme", namespace: "acme", expected: false},
+		{input: "nginx", namespace: "library", expected: true},
+		{input: "docker.io/library/nginx", namespace: "nginx", expected: false},
+		{input: "acme/app", namespace: "", expected: false},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input+"/"+testcase.namespace, func(t *testing.T) {
+			t.Parallel()
+			for _, named := range []Named{mustParseNormalized(t, testcase.input), mustParseNamed(t, testcase.input)} {
+				if in := InNamespace(named, testcase.namespace); in != testcase.expected {
+					t.Errorf("InNamespace(%q, %q): got %v, expected %v", named, testcase.namespace, in, testcase.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestRepositoryColorHash(t *testing.T) {
+	t.Parallel()
+	// The expected hashes lock the hash function, as changing it would
+	// change the colors of all repositories.
+	testcases := []struct {
+		inputs   []string
+		expected uint32
+	}{
+		{
+			inputs:   []string{"nginx", "nginx:1.25", "docker.io/library/nginx", "index.docker.io/library/nginx@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"},
+			expected: 0xba55bc26,
+		},
+		{
+			inputs:   []string{"user/app", "docker.io/user/app:1.0"},
+			expected: 0x8fc84621,
+		},
+		{
+			inputs:   []string{"example.com/app", "example.com/app:1.0"},
+			expected: 0x3529ab0e,
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.inputs[0], func(t *testing.T) {
+			t.Parallel()
+			for _, input := range testcase.inputs {
+				if hash := RepositoryColorHash(mustParseNormalized(t, input)); hash != testcase.expected {
+					t.Errorf("%q: unexpected hash: got %#x, expected %#x", input, hash, testcase.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestRefSuffix(t *testing.T) {
+	t.Parallel()
+	const dgst = "sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+	testcases := []struct {
+		ref      Reference
+		expected string
+	}{
+		{ref: mustParseNormalized(t, "nginx"), expected: ""},
+		{ref: mustParseNormalized(t, "nginx:1.25"), expected: ":1.25"},
+		{ref: mustParseNormalized(t, "nginx@"+dgst), expected: "@" + dgst},
+		{ref: mustParseNormalized(t, "localhost:5000/app:1.25@"+dgst), expected: ":1.25@" + dgst},
+		{ref: digestReference(dgst), expected: "@" + dgst},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.ref.String(), func(t *testing.T) {
+			t.Parallel()
+			suffix := RefSuffix(testcase.ref)
+			if suffix != testcase.expected {
+				t.Errorf("unexpected suffix: got %q, expected %q", suffix, testcase.expected)
+			}
+			if named, ok := testcase.ref.(Named); ok && named.Name()+suffix != named.String() {
+				t.Errorf("name and suffix %q do not form %q", named.Name()+suffix, named.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package reference
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ParseLenient parses s in the same way as [ParseNormalizedNamed], but
+// tolerates common mistakes in user input that the strict parser rejects.
+// The following corrections are applied before parsing:
+//
+//   - Leading and trailing control characters (such as "\r" left by CRLF line
+//     endings, or NUL), spaces, and byte order marks are removed, as often
+//     found in CSV exports. Control characters within the reference are
+//     still rejected with [ErrControlCharacter].
+//   - A single pair of angle brackets enclosing the reference is removed
+//     ("<docker.io/library/nginx>"), as added by auto-linking in some issue
+//     trackers and Markdown renderers. Unbalanced brackets are still
+//     rejected with [ErrAngleBrackets].
+//   - Runs of slashes are collapsed into a single slash ("docker//docker"),
+//     as often produced by joining paths. A "://" sequence is kept, so that
+//     URLs are still rejected.
+//   - A single trailing slash is removed ("docker.io/library/nginx/"). The
+//     slash is kept if the input has no other slash, as "docker/" is a
+//     namespace without a repository name rather than a repository.
+//
+// References containing unexpanded variables (see [IsTemplated]) cannot be
+// parsed, and [ErrTemplatedReference] is returned for them instead of a
+// format error, so that callers can skip or defer them.
+func ParseLenient(s string) (Named, error) {
+	if IsTemplated(s) {
+		return nil, ErrTemplatedReference
+	}
+	return parseNormalized(trimTrailingSlash(collapseSlashes(trimAngleBrackets(trimControlCharacters(s)))))
+}
+
+// trimControlCharacters removes leading and trailing ASCII control
+// characters, spaces, and byte order marks from s.
+func trimControlCharacters(s string) string {
+	return strings.TrimFunc(s, func(r rune) bool {
+		return r <= ' ' || r == 0x7f || r == '\ufeff'
+	})
+}
+
+// trimAngleBrackets removes a single pair of angle brackets enclosing s.
+func trimAngleBrackets(s string) string {
+	if len(s) >= 2 && s[0] == '<' && s[len(s)-1] == '>' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// ParseURLDecoded parses s in the same way as [ParseNormalizedNamed], after
+// decoding percent-encoded characters, for references copied from URLs (for
+// example, "library%2Fnginx" is parsed as "library/nginx"). The input is
+// decoded once; an error is returned if it is not validly encoded.
+func ParseURLDecoded(s string) (Named, error) {
+	decoded, err := url.PathUnescape(s)
+	if err != nil {
+		return nil, err
+	}
+	return parseNormalized(decoded)
+}
+
+// collapseSlashes replaces runs of slashes in s with a single slash, except
+// for "://" sequences.
+func collapseSlashes(s string) string {
+	parts := strings.Split(s, "://")
+	for i, part := range parts {
+		for strings.Contains(part, "//") {
+			part = strings.ReplaceAll(part, "//", "/")
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, "://")
+}
+
+// trimTrailingSlash removes a single trailing slash from s, unless s has no
+// other slash.
+func trimTrailingSlash(s string) string {
+	if trimmed := strings.TrimSuffix(s, "/"); trimmed != s && strings.ContainsRune(trimmed, '/') {
+		return trimmed
+	}
+	return s
+}
@@ -0,0 +1,177 @@
+package reference
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseLenient(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input     string
+		expected  string
+		strictErr bool
+		err       bool
+	}{
+		{
+			input:    "docker.io/library/nginx",
+			expected: "docker.io/library/nginx",
+		},
+		{
+			input:     "docker.io/library/nginx/",
+			expected:  "docker.io/library/nginx",
+			strictErr: true,
+		},
+		{
+			input:     "library/nginx:1.25/",
+			expected:  "docker.io/library/nginx:1.25",
+			strictErr: true,
+		},
+		{
+			input:     "docker/",
+			strictErr: true,
+			err:       true,
+		},
+		{
+			input:     "docker//docker",
+			expected:  "docker.io/docker/docker",
+			strictErr: true,
+		},
+		{
+			input:     "docker///docker",
+			expected:  "docker.io/docker/docker",
+			strictErr: true,
+		},
+		{
+			input:     "example.com//team///app:1.0",
+			expected:  "example.com/team/app:1.0",
+			strictErr: true,
+		},
+		{
+			input:     "docker.io/library/nginx//",
+			expected:  "docker.io/library/nginx",
+			strictErr: true,
+		},
+		{
+			input:     "https://github.com/docker/docker",
+			strictErr: true,
+			err:       true,
+		},
+		{
+			input:     "example.com/app:1.0\r\n",
+			expected:  "example.com/app:1.0",
+			strictErr: true,
+		},
+		{
+			input:     "\ufeffnginx",
+			expected:  "docker.io/library/nginx",
+			strictErr: true,
+		},
+		{
+			input:     "\x00\tnginx:latest \x7f",
+			expected:  "docker.io/library/nginx:latest",
+			strictErr: true,
+		},
+		{
+			input:     "example.com/app\x00:1.0",
+			strictErr: true,
+			err:       true,
+		},
+		{
+			input:     "<docker.io/library/nginx>",
+			expected:  "docker.io/library/nginx",
+			strictErr: true,
+		},
+		{
+			input:     "<nginx:1.25>\r\n",
+			expected:  "docker.io/library/nginx:1.25",
+			strictErr: true,
+		},
+		{
+			input:     "<<nginx>>",
+			strictErr: true,
+			err:       true,
+		},
+		{
+			input:     "<nginx",
+			strictErr: true,
+			err:       true,
+		},
+		{
+			input:     "nginx>",
+			strictErr: true,
+			err:       true,
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			if _, err := ParseNormalizedNamed(testcase.input); (err != nil) != testcase.strictErr {
+				t.Errorf("unexpected strict parse result: error %v, expected error: %v", err, testcase.strictErr)
+			}
+			named, err := ParseLenient(testcase.input)
+			if testcase.err {
+				if err == nil {
+					t.Errorf("expected error, got %q", named.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if named.String() != testcase.expected {
+				t.Errorf("unexpected: got %q, expected %q", named.String(), testcase.expected)
+			}
+		})
+	}
+}
+
+func TestParseLenientAngleBrackets(t *testing.T) {
+	t.Parallel()
+	for _, input := range []string{"<docker.io/library/nginx>", "<nginx", "nginx>"} {
+		if _, err := ParseNormalizedNamed(input); !errors.Is(err, ErrAngleBrackets) || !errors.Is(err, ErrReferenceInvalidFormat) {
+			t.Errorf("%q: unexpected strict error: got %v, expected %v", input, err, ErrAngleBrackets)
+		}
+	}
+	for _, input := range []string{"<nginx", "nginx>", "<<nginx>>"} {
+		if _, err := ParseLenient(input); !errors.Is(err, ErrAngleBrackets) {
+			t.Errorf("%q: unexpected lenient error: got %v, expected %v", input, err, ErrAngleBrackets)
+		}
+	}
+}
+
+func TestParseURLDecoded(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "library%2Fnginx", expected: "docker.io/library/nginx"},
+		{input: "library%2fnginx%3A1.25", expected: "docker.io/library/nginx:1.25"},
+		{input: "example.com%2Fapp", expected: "example.com/app"},
+		{input: "example.com/app:1.0", expected: "example.com/app:1.0"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			// Without decoding, a specific error is returned.
+			if _, err := ParseNormalizedNamed(testcase.input); err != nil && !errors.Is(err, ErrPercentEncoded) {
+				t.Errorf("unexpected error: got %v, expected %v", err, ErrPercentEncoded)
+			}
+			named, err := ParseURLDecoded(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if named.String() != testcase.expected {
+				t.Errorf("unexpected reference: got %q, expected %q", named.String(), testcase.expected)
+			}
+		})
+	}
+	for _, input := range []string{"library%2", "library%252Fnginx"} {
+		if named, err := ParseURLDecoded(input); err == nil {
+			t.Errorf("%q: expected error, got %q", input, named.String())
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Package lookalike provides a heuristic check for registry domains that look
+// like, but are not, a trusted domain, such as "dockеr.io" (with a Cyrillic
+// "е") for "docker.io".
+//
+// It is kept out of the reference package, as it depends on
+// golang.org/x/net/idna and golang.org/x/text/unicode/norm.
+package lookalike
+
+import (
+	"strings"
+
+	"github.com/distribution/distribution/v3/reference"
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+// lookalikes maps characters that are commonly mistaken for a Latin letter or
+// digit, such as the Cyrillic "е" (U+0435), to the character they are
+// mistaken for. It is a small, hand-written selection, inspired by the
+// confusables of Unicode Technical Standard #39 but not generated from them;
+// characters are mapped after case folding.
+var lookalikes = map[rune]rune{
+	// Digits that are mistaken for letters.
+	'0': 'o',
+	'1': 'l',
+
+	// Latin.
+	'ı': 'i', // U+0131 LATIN SMALL LETTER DOTLESS I
+	'ɑ': 'a', // U+0251 LATIN SMALL LETTER ALPHA
+	'ɡ': 'g', // U+0261 LATIN SMALL LETTER SCRIPT G
+	'ɩ': 'i', // U+0269 LATIN SMALL LETTER IOTA
+
+	// Greek.
+	'α': 'a', // U+03B1 GREEK SMALL LETTER ALPHA
+	'ι': 'i', // U+03B9 GREEK SMALL LETTER IOTA
+	'κ': 'k', // U+03BA GREEK SMALL LETTER KAPPA
+	'ν': 'v', // U+03BD GREEK SMALL LETTER NU
+	'ο': 'o', // U+03BF GREEK SMALL LETTER OMICRON
+	'ρ': 'p', // U+03C1 GREEK SMALL LETTER RHO
+	'υ': 'u', // U+03C5 GREEK SMALL LETTER UPSILON
+
+	// Cyrillic.
+	'а': 'a', // U+0430 CYRILLIC SMALL LETTER A
+	'в': 'b', // U+0432 CYRILLIC SMALL LETTER VE
+	'е': 'e', // U+0435 CYRILLIC SMALL LETTER IE
+	'к': 'k', // U+043A CYRILLIC SMALL LETTER KA
+	'м': 'm', // U+043C CYRILLIC SMALL LETTER EM
+	'н': 'h', // U+043D CYRILLIC SMALL LETTER EN
+	'о': 'o', // U+043E CYRILLIC SMALL LETTER O
+	'р': 'p', // U+0440 CYRILLIC SMALL LETTER ER
+	'с': 'c', // U+0441 CYRILLIC SMALL LETTER ES
+	'т': 't', // U+0442 CYRILLIC SMALL LETTER TE
+	'у': 'y', // U+0443 CYRILLIC SMALL LETTER U
+	'х': 'x', // U+0445 CYRILLIC SMALL LETTER HA
+	'ѕ': 's', // U+0455 CYRILLIC SMALL LETTER DZE
+	'і': 'i', // U+0456 CYRILLIC SMALL LETTER BYELORUSSIAN-UKRAINIAN I
+	'ј': 'j', // U+0458 CYRILLIC SMALL LETTER JE
+	'һ': 'h', // U+04BB CYRILLIC SMALL LETTER SHHA
+	'ԁ': 'd', // U+0501 CYRILLIC SMALL LETTER KOMI DE
+	'ԛ': 'q', // U+051B CYRILLIC SMALL LETTER QA
+	'ԝ': 'w', // U+051D CYRILLIC SMALL LETTER WE
+
+	// Armenian.
+	'հ': 'h', // U+0570 ARMENIAN SMALL LETTER HO
+	'ո': 'n', // U+0578 ARMENIAN SMALL LETTER VO
+	'ս': 'u', // U+057D ARMENIAN SMALL LETTER SEH
+	'ց': 'g', // U+0581 ARMENIAN SMALL LETTER CO
+	'օ': 'o', // U+0585 ARMENIAN SMALL LETTER OH
+}
+
+// CheckDomain reports whether the domain of ref looks like, but is not the
+// same as, any of the domains in protected, and returns the first such
+// domain; for example, "xn--dockr-2we.io" (which is displayed as "dockеr.io",
+// with a Cyrillic "е") looks like "docker.io". This can be used to detect
+// typo-squatting of trusted registries.
+//
+// This is a partial heuristic, not the confusable detection of Unicode
+// Technical Standard #39, and lookalikes that are not in its table are not
+// detected. Domains are compared without their port. Internationalized
+// domains are decoded from their punycode ("xn--") form, normalized to NFKC,
+// and case folded. Characters that look like a Latin letter or digit are then
+// replaced by the character they look like, and every "rn" by "m", before
+// comparison.
+func CheckDomain(ref reference.Named, protected []string) (bool, string) {
+	host := stripPort(reference.Domain(ref))
+	key := skeleton(host)
+	for _, domain := range protected {
+		trusted := stripPort(domain)
+		if strings.EqualFold(host, trusted) {
+			continue
+		}
+		if skeleton(trusted) == key {
+			return true, domain
+		}
+	}
+	return false, ""
+}
+
+// stripPort returns domain without its port (if any). The brackets of an
+// IPv6 host are preserved.
+func stripPort(domain string) string {
+	i := strings.LastIndexByte(domain, ':')
+	if i == -1 || strings.LastIndexByte(domain, ']') > i {
+		return domain
+	}
+	return domain[:i]
+}
+
+// skeleton returns a string that is the same for domains that look alike, as
+// described by [CheckDomain].
+func skeleton(domain string) string {
+	if decoded, err := idna.ToUnicode(domain); err == nil {
+		domain = decoded
+	}
+	domain = strings.ToLower(norm.NFKC.String(domain))
+	domain = strings.Map(func(r rune) rune {
+		if c, ok := lookalikes[r]; ok {
+			return c
+		}
+		return r
+	}, domain)
+	return strings.ReplaceAll(domain, "rn", "m")
+}
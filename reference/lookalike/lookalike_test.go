@@ -0,0 +1,46 @@
+package lookalike
+
+import (
+	"testing"
+
+	"github.com/distribution/distribution/v3/reference"
+)
+
+func TestCheckDomain(t *testing.T) {
+	t.Parallel()
+	protected := []string{"docker.io", "gcr.io", "registry.example.com:5000"}
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		// "dockеr.io", with a Cyrillic "е".
+		{input: "xn--dockr-2we.io/library/nginx", expected: "docker.io"},
+		// "gсr.io", with a Cyrillic "с".
+		{input: "xn--gr-omc.io/project/app", expected: "gcr.io"},
+		// "dօcker.io", with an Armenian "օ".
+		{input: "xn--dcker-lkg.io/library/nginx", expected: "docker.io"},
+		// "registry.exɑmple.com", with a Latin alpha "ɑ".
+		{input: "registry.xn--exmple-cxc.com/app", expected: "registry.example.com:5000"},
+		{input: "d0cker.io/library/nginx", expected: "docker.io"},
+		{input: "registry.examp1e.com/app", expected: "registry.example.com:5000"},
+		{input: "docker.io/library/nginx"},
+		{input: "registry.example.com:443/app"},
+		{input: "nginx"},
+		{input: "quay.io/project/app"},
+		{input: "dockerhub.io/library/nginx"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			named, err := reference.ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			found, domain := CheckDomain(named, protected)
+			if found != (testcase.expected != "") || domain != testcase.expected {
+				t.Errorf("unexpected result: got (%v, %q), expected %q", found, domain, testcase.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package reference
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// MetricLabelMax is the maximum length of the values returned by
+// [MetricLabel].
+const MetricLabelMax = 128
+
+// metricLabelHashLen is the number of hexadecimal characters of the hash
+// appended to truncated metric labels.
+const metricLabelHashLen = 12
+
+// MetricLabel returns the familiar string of ref (see [FamiliarString]) in a
+// form that is safe to use as the value of a metrics label, such as a
+// Prometheus label. Characters other than ASCII letters, digits, and
+// "._-/:@" are replaced with "_", and values longer than [MetricLabelMax] are
+// truncated, and suffixed with "~" and a hash of the full string, so that
+// distinct references result in distinct labels. The result is
+// deterministic.
+func MetricLabel(ref Named) string {
+	s := FamiliarString(ref)
+	label := strings.Map(func(r rune) rune {
+		if r < 0x80 && (isAlphanumeric(byte(r)) || strings.ContainsRune("._-/:@", r)) {
+			return r
+		}
+		return '_'
+	}, s)
+	if len(label) <= MetricLabelMax {
+		return label
+	}
+	sum := sha256.Sum256([]byte(s))
+	return label[:MetricLabelMax-metricLabelHashLen-1] + "~" + hex.EncodeToString(sum[:])[:metricLabelHashLen]
+}
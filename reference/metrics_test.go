@@ -0,0 +1,41 @@
+package reference
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMetricLabel(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    Named
+		expected string
+	}{
+		{input: mustParseNormalized(t, "nginx:1.25"), expected: "nginx:1.25"},
+		{input: mustParseNormalized(t, "example.com:5000/team/app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"), expected: "example.com:5000/team/app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		// Named implementations that do not enforce the grammar may hold
+		// characters that are not allowed in labels.
+		{input: repository{domain: "exämple.com", path: "app name"}, expected: "ex_mple.com/app_name"},
+	}
+	for _, testcase := range testcases {
+		if label := MetricLabel(testcase.input); label != testcase.expected {
+			t.Errorf("%q: unexpected label: got %q, expected %q", testcase.input.String(), label, testcase.expected)
+		}
+	}
+
+	long1 := mustParseNormalized(t, "example.com/"+strings.Repeat("a/", 100)+"app1:1.0")
+	long2 := mustParseNormalized(t, "example.com/"+strings.Repeat("a/", 100)+"app2:1.0")
+	label1, label2 := MetricLabel(long1), MetricLabel(long2)
+	if len(label1) != MetricLabelMax || len(label2) != MetricLabelMax {
+		t.Errorf("unexpected label lengths: got %d and %d, expected %d", len(label1), len(label2), MetricLabelMax)
+	}
+	if label1 == label2 {
+		t.Errorf("distinct references have the same label %q", label1)
+	}
+	if again := MetricLabel(long1); again != label1 {
+		t.Errorf("label is not deterministic: got %q and %q", label1, again)
+	}
+	if !strings.HasPrefix(label1, "example.com/a/a/") || !strings.Contains(label1, "~") {
+		t.Errorf("unexpected truncated label: %q", label1)
+	}
+}
@@ -0,0 +1,64 @@
+package reference
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ToPullThrough rewrites ref to the namespace of a pull-through cache at
+// cacheDomain. prefixPerUpstream maps the domain of each upstream registry
+// to the path prefix under which the cache exposes it. Upstream domains are
+// compared using [CanonicalDomain], so "docker.io" maps all aliases of Docker
+// Hub. The tag and digest of ref are preserved. For example, with the prefix
+// "dockerhub" for "docker.io", "docker.io/library/nginx:1.25" is rewritten to
+// "<cacheDomain>/dockerhub/library/nginx:1.25".
+//
+// An error is returned if the domain of ref has no prefix in
+// prefixPerUpstream, or if the rewritten reference is invalid.
+func ToPullThrough(ref Named, cacheDomain string, prefixPerUpstream map[string]string) (Named, error) {
+	upstream := CanonicalDomain(Domain(ref))
+	prefix, ok := prefixPerUpstream[upstream]
+	if !ok {
+		for domain, p := range prefixPerUpstream {
+			if CanonicalDomain(domain) == upstream {
+				prefix, ok = p, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("no pull-through cache prefix configured for upstream %q", upstream)
+	}
+	path := Path(ref)
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		path = prefix + "/" + path
+	}
+	name, err := Join(cacheDomain, path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pull-through reference %q: %w", cacheDomain+"/"+path, err)
+	}
+	return withRepository(ref, name.(repository)), nil
+}
+
+// ResolveMirror rewrites ref to its upstream registry if the domain of ref
+// is a mirror in mirrorToUpstream, which maps the domains of mirrors to the
+// domains of the registries they mirror. The path, tag, and digest of ref are
+// preserved; if the upstream is Docker Hub, the "library/" prefix is added to
+// single-component paths, as done by [ParseNormalizedNamed]. References on
+// other domains are returned unchanged. An error is returned if the rewritten
+// reference is invalid.
+func ResolveMirror(ref Named, mirrorToUpstream map[string]string) (Named, error) {
+	upstream, ok := mirrorToUpstream[Domain(ref)]
+	if !ok {
+		return ref, nil
+	}
+	path := Path(ref)
+	if CanonicalDomain(upstream) == defaultDomain && !strings.ContainsRune(path, '/') {
+		path = officialRepoPrefix + path
+	}
+	name, err := Join(upstream, path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upstream reference %q: %w", upstream+"/"+path, err)
+	}
+	return withRepository(ref, name.(repository)), nil
+}
@@ -0,0 +1,118 @@
+package reference
+
+import (
+	"testing"
+)
+
+func TestToPullThrough(t *testing.T) {
+	t.Parallel()
+	prefixes := map[string]string{
+		"docker.io": "dockerhub",
+		"gcr.io":    "gcr",
+	}
+	testcases := []struct {
+		input    string
+		expected string
+		err      bool
+	}{
+		{
+			input:    "nginx:1.25",
+			expected: "cache.internal/dockerhub/library/nginx:1.25",
+		},
+		{
+			input:    "index.docker.io/user/app",
+			expected: "cache.internal/dockerhub/user/app",
+		},
+		{
+			input:    "gcr.io/project/app@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+			expected: "cache.internal/gcr/project/app@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+		},
+		{
+			input: "quay.io/org/app:1.0",
+			err:   true,
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rewritten, err := ToPullThrough(ref, "cache.internal", prefixes)
+			if testcase.err {
+				if err == nil {
+					t.Errorf("expected error, got %q", rewritten.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rewritten.String() != testcase.expected {
+				t.Errorf("unexpected: got %q, expected %q", rewritten.String(), testcase.expected)
+			}
+		})
+	}
+
+	ref, err := ParseNormalizedNamed("nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ToPullThrough(ref, "-invalid", prefixes); err == nil {
+		t.Error("expected error for invalid cache domain")
+	}
+	rewritten, err := ToPullThrough(ref, "cache.internal", map[string]string{"index.docker.io": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "cache.internal/library/nginx"; rewritten.String() != expected {
+		t.Errorf("unexpected: got %q, expected %q", rewritten.String(), expected)
+	}
+}
+
+func TestResolveMirror(t *testing.T) {
+	t.Parallel()
+	mirrors := map[string]string{
+		"mirror.example.com":     "docker.io",
+		"gcr-mirror.example.com": "gcr.io",
+		"broken-mirror.local":    "Invalid_Domain",
+	}
+	testcases := []struct {
+		input    string
+		expected string
+		err      bool
+	}{
+		{input: "mirror.example.com/library/nginx:1.25", expected: "docker.io/library/nginx:1.25"},
+		{input: "mirror.example.com/nginx", expected: "docker.io/library/nginx"},
+		{input: "mirror.example.com/user/app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", expected: "docker.io/user/app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{input: "gcr-mirror.example.com/project/app:1.0", expected: "gcr.io/project/app:1.0"},
+		{input: "registry.example.com/team/app:1.0", expected: "registry.example.com/team/app:1.0"},
+		{input: "nginx:1.25", expected: "docker.io/library/nginx:1.25"},
+		{input: "broken-mirror.local/app", err: true},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			named, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resolved, err := ResolveMirror(named, mirrors)
+			if testcase.err {
+				if err == nil {
+					t.Fatalf("expected error, got %q", resolved.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resolved.String() != testcase.expected {
+				t.Errorf("unexpected reference: got %q, expected %q", resolved.String(), testcase.expected)
+			}
+		})
+	}
+}
@@ -39,6 +39,39 @@ const (
 	defaultTag = "latest"
 )
 
+// Events reported to [NormalizationHook].
+const (
+	// EventDefaultDomain is reported when the default domain ("docker.io")
+	// is added to a reference without a domain.
+	EventDefaultDomain = "default-domain"
+
+	// EventLegacyDomain is reported when the legacy domain of Docker Hub
+	// ("index.docker.io") is replaced with the default domain.
+	EventLegacyDomain = "legacy-domain"
+
+	// EventLibraryPrefix is reported when the "library/" prefix for official
+	// images is added to a single-segment name on Docker Hub.
+	EventLibraryPrefix = "library-prefix"
+
+	// EventDefaultTag is reported by [ParseDockerRef] when the default tag
+	// ("latest") is added to a reference without a tag or digest.
+	EventDefaultTag = "default-tag"
+)
+
+// NormalizationHook, if set, is called by [ParseNormalizedNamed] and
+// [ParseDockerRef] for each implicit decision made while successfully
+// normalizing input, with one of the Event constants (such as
+// [EventDefaultDomain]) as event. It can be used to collect metrics about how
+// often each normalization happens. It is nil by default, in which case
+// there is no overhead. Other functions of this package, including those
+// that parse references internally, do not call NormalizationHook, so that
+// each reference parsed by the caller is only counted once.
+//
+// NormalizationHook is read without synchronization: it must only be set
+// during initialization, before any references are parsed, and the function
+// must be safe for concurrent use, as parsing may happen concurrently.
+var NormalizationHook func(event string, input string)
+
 // normalizedNamed represents a name which has been
 // normalized and has a familiar form. A familiar name
 // is what is used in Docker UI. An example normalized
@@ -54,10 +87,45 @@ type normalizedNamed interface {
 // qualified reference. If the value may be an identifier
 // use ParseAnyReference.
 func ParseNormalizedNamed(s string) (Named, error) {
+	named, err := parseNormalized(s)
+	if err != nil {
+		return nil, err
+	}
+	callNormalizationHook(s)
+	return named, nil
+}
+
+// parseNormalized parses s in the same way as [ParseNormalizedNamed], without
+// calling NormalizationHook, for use by the other functions of this package,
+// so that NormalizationHook is only called once for each reference parsed by
+// the caller.
+func parseNormalized(s string) (Named, error) {
+	return parseNormalizedNamed(s, isDomainComponent)
+}
+
+// callNormalizationHook calls NormalizationHook, if set, for each implicit
+// decision made while normalizing the valid familiar reference s.
+func callNormalizationHook(s string) {
+	if hook := NormalizationHook; hook != nil {
+		for _, event := range normalizationEvents(s, isDomainComponent) {
+			hook(event, s)
+		}
+	}
+}
+
+// parseNormalizedNamed is the implementation of [ParseNormalizedNamed],
+// using isDomain to decide whether the first component of s is a domain.
+func parseNormalizedNamed(s string, isDomain func(component string) bool) (Named, error) {
 	if ok := anchoredIdentifierRegexp.MatchString(s); ok {
 		return nil, fmt.Errorf("invalid repository name (%s), cannot specify 64-byte hexadecimal strings", s)
 	}
-	domain, remainder := splitDockerDomain(s)
+	if isPercentEncoded(s) {
+		return nil, ErrPercentEncoded
+	}
+	if strings.HasPrefix(s, "<") || strings.HasSuffix(s, ">") {
+		return nil, ErrAngleBrackets
+	}
+	domain, remainder := splitDockerDomain(s, isDomain)
 	var remote string
 	if tagSep := strings.IndexRune(remainder, ':'); tagSep > -1 {
 		remote = remainder[:tagSep]
@@ -79,6 +147,49 @@ func ParseNormalizedNamed(s string) (Named, error) {
 	return named, nil
 }
 
+// ParseAllNormalized parses each of inputs as done by
+// [ParseNormalizedNamed]. If all inputs are valid, it returns the parsed
+// references, in the same order as inputs, and an index of -1. Otherwise,
+// parsing stops at the first invalid input, and the references parsed before
+// it are returned along with its index and error.
+func ParseAllNormalized(inputs []string) ([]Named, int, error) {
+	refs := make([]Named, 0, len(inputs))
+	for i, input := range inputs {
+		named, err := parseNormalized(input)
+		if err != nil {
+			return refs, i, err
+		}
+		refs = append(refs, named)
+	}
+	return refs, -1, nil
+}
+
+// ParseNormalizedNamedRaw parses s in the same way as [ParseNormalizedNamed],
+// and additionally returns the reference exactly as it was written in s,
+// without normalization; the original reference has no domain if s has no
+// domain, and the "library/" prefix is not added. For example, for "nginx:1.25"
+// the normalized reference is "docker.io/library/nginx:1.25", and the original
+// reference is "nginx:1.25". Comparing both allows showing which parts were
+// added by normalization.
+func ParseNormalizedNamedRaw(s string) (normalized Named, original Reference, err error) {
+	normalized, err = parseNormalized(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	var domain string
+	name := s
+	if i := strings.IndexRune(name, '/'); i != -1 && isDomainComponent(name[:i]) {
+		domain, name = name[:i], name[i+1:]
+	}
+	if i := strings.IndexRune(name, '@'); i != -1 {
+		name = name[:i]
+	}
+	if i := strings.IndexRune(name, ':'); i != -1 {
+		name = name[:i]
+	}
+	return normalized, withRepository(normalized, repository{domain: domain, path: name}), nil
+}
+
 // namedTaggedDigested is a reference that has both a tag and a digest.
 type namedTaggedDigested interface {
 	NamedTagged
@@ -105,10 +216,20 @@ type namedTaggedDigested interface {
 //	// Already a named reference
 //	docker.io/library/busybox:latest
 func ParseDockerRef(ref string) (Named, error) {
-	named, err := ParseNormalizedNamed(ref)
+	named, err := parseNormalized(ref)
 	if err != nil {
 		return nil, err
 	}
+	callNormalizationHook(ref)
+	if hook := NormalizationHook; hook != nil && IsNameOnly(named) {
+		hook(EventDefaultTag, ref)
+	}
+	return dockerRef(named)
+}
+
+// dockerRef converts a normalized reference to the form returned by
+// [ParseDockerRef].
+func dockerRef(named Named) (Named, error) {
 	if canonical, ok := named.(namedTaggedDigested); ok {
 		// The reference is both tagged and digested; only return digested.
 		newNamed, err := WithName(canonical.Name())
@@ -120,12 +241,30 @@ func ParseDockerRef(ref string) (Named, error) {
 	return TagNameOnly(named), nil
 }
 
-// splitDockerDomain splits a repository name to domain and remote-name.
+// JoinHostname is the inverse of [SplitHostname] for normalized references:
+// it joins domain and name, as returned by SplitHostname, into a named
+// reference, and validates the result. It normalizes in the same way as
+// [ParseNormalizedNamed]: an empty domain or the legacy domain
+// ("index.docker.io") is replaced with the default domain ("docker.io"), and
+// the "library/" prefix is added to single-component names on the default
+// domain. As such, SplitHostname followed by JoinHostname round-trips.
+func JoinHostname(domain, name string) (Named, error) {
+	if domain == "" || domain == legacyDefaultDomain {
+		domain = defaultDomain
+	}
+	if domain == defaultDomain && name != "" && !strings.ContainsRune(name, '/') {
+		name = officialRepoPrefix + name
+	}
+	return Join(domain, name)
+}
+
+// splitDockerDomain splits a repository name to domain and remote-name,
+// using isDomain to decide whether the first component is a domain.
 // If no valid domain is found, the default domain is used. Repository name
 // needs to be already validated before.
-func splitDockerDomain(name string) (domain, remainder string) {
+func splitDockerDomain(name string, isDomain func(component string) bool) (domain, remainder string) {
 	i := strings.IndexRune(name, '/')
-	if i == -1 || (!strings.ContainsAny(name[:i], ".:") && name[:i] != localhost && strings.ToLower(name[:i]) == name[:i]) {
+	if i == -1 || !isDomain(name[:i]) {
 		domain, remainder = defaultDomain, name
 	} else {
 		domain, remainder = name[:i], name[i+1:]
@@ -139,6 +278,181 @@ func splitDockerDomain(name string) (domain, remainder string) {
 	return
 }
 
+// normalizationEvents returns the events for the implicit decisions made by
+// splitDockerDomain for the valid familiar reference s.
+func normalizationEvents(s string, isDomain func(component string) bool) []string {
+	var events []string
+	remainder := s
+	if i := strings.IndexRune(s, '/'); i == -1 || !isDomain(s[:i]) {
+		events = append(events, EventDefaultDomain)
+	} else if domain := s[:i]; domain == legacyDefaultDomain || domain == defaultDomain {
+		if domain == legacyDefaultDomain {
+			events = append(events, EventLegacyDomain)
+		}
+		remainder = s[i+1:]
+	} else {
+		return nil
+	}
+	if !strings.ContainsRune(remainder, '/') {
+		events = append(events, EventLibraryPrefix)
+	}
+	return events
+}
+
+// isDomainComponent reports whether the first component of a familiar name
+// is a domain rather than the first path-component of the remote-name. A
+// component is considered a domain if it contains a "." or ":", if it is
+// "localhost", or if it contains uppercase characters (which are not allowed
+// in path-components).
+func isDomainComponent(component string) bool {
+	return strings.ContainsAny(component, ".:") || component == localhost || strings.ToLower(component) != component
+}
+
+// NormalizationDiff parses s in the same way as [ParseNormalizedNamed], and
+// returns the full string of the normalized reference, along with a short
+// description of each change made to s by normalization, in the order in
+// which they were made; for example, "added domain docker.io" and "added
+// library/ prefix" for "nginx". No changes are returned if s is already
+// fully qualified. Unlike [ExplainNormalization], which describes the
+// reasoning behind each decision, the changes only describe the difference
+// between s and the full string, for teaching users what normalization does.
+func NormalizationDiff(s string) (full string, changes []string, err error) {
+	named, err := parseNormalized(s)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, event := range normalizationEvents(s, isDomainComponent) {
+		switch event {
+		case EventDefaultDomain:
+			changes = append(changes, "added domain "+defaultDomain)
+		case EventLegacyDomain:
+			changes = append(changes, "replaced domain "+legacyDefaultDomain+" with "+defaultDomain)
+		case EventLibraryPrefix:
+			changes = append(changes, "added "+officialRepoPrefix+" prefix")
+		}
+	}
+	return named.String(), changes, nil
+}
+
+// ExplainNormalization parses s in the same way as [ParseNormalizedNamed],
+// and additionally returns human-readable notes describing each implicit
+// decision made while normalizing s, such as adding the default domain or
+// the "library/" prefix, or treating the first component as a path instead
+// of a domain.
+func ExplainNormalization(s string) (Named, []string, error) {
+	named, err := parseNormalized(s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var notes []string
+	remainder := s
+	if i := strings.IndexRune(s, '/'); i == -1 {
+		notes = append(notes, fmt.Sprintf("no domain specified, using default domain %q", defaultDomain))
+	} else if first := s[:i]; !isDomainComponent(first) {
+		notes = append(notes, fmt.Sprintf("%q is treated as a path component instead of a domain because it contains no \".\" or \":\" and is not %q; using default domain %q", first, localhost, defaultDomain))
+	} else {
+		notes = append(notes, fmt.Sprintf("%q is treated as a domain", first))
+		if first == legacyDefaultDomain {
+			notes = append(notes, fmt.Sprintf("legacy domain %q is replaced with %q", legacyDefaultDomain, defaultDomain))
+		}
+		remainder = s[i+1:]
+	}
+	if Domain(named) == defaultDomain && !strings.ContainsRune(remainder, '/') {
+		notes = append(notes, fmt.Sprintf("%q prefix added for official image", officialRepoPrefix))
+	}
+	return named, notes, nil
+}
+
+// HasExplicitDomain reports whether s, a familiar or fully qualified
+// reference, includes a domain, or whether it relies on the default domain
+// being added by normalization. For example, it returns false for "nginx",
+// "library/nginx", and "test_com/foo", and true for "docker.io/nginx" and
+// "localhost/foo". An error is returned if s is not a valid reference.
+func HasExplicitDomain(s string) (bool, error) {
+	if _, err := parseNormalized(s); err != nil {
+		return false, err
+	}
+	i := strings.IndexRune(s, '/')
+	return i != -1 && isDomainComponent(s[:i]), nil
+}
+
+// ParseUnqualified reports whether s is a bare (unqualified) name, without
+// a domain, and returns the name without its domain (if any), including the
+// tag and digest as written. For example, "nginx:1.25" and "library/nginx"
+// are bare, and returned unchanged, and "docker.io/nginx" is qualified, with
+// the name "nginx". Callers can use this to resolve bare names against their
+// own list of search registries, instead of the default domain. An error is
+// returned if s is not a valid reference according to
+// [ParseNormalizedNamed].
+func ParseUnqualified(s string) (bare bool, name string, err error) {
+	if _, err := parseNormalized(s); err != nil {
+		return false, "", err
+	}
+	if i := strings.IndexRune(s, '/'); i != -1 && isDomainComponent(s[:i]) {
+		return false, s[i+1:], nil
+	}
+	return true, s, nil
+}
+
+// OfficialName returns the "library/"-qualified path of ref, and true if ref
+// is an official image on Docker Hub with a single-segment name (for example,
+// "library/nginx" for "docker.io/library/nginx"). This is the name to use
+// with the registry API, and is equal to [Path] for such references. False is
+// returned for all other references.
+func OfficialName(ref Named) (string, bool) {
+	if Domain(ref) != defaultDomain {
+		return "", false
+	}
+	path := Path(ref)
+	if !strings.HasPrefix(path, officialRepoPrefix) || strings.ContainsRune(path[len(officialRepoPrefix):], '/') {
+		return "", false
+	}
+	return path, true
+}
+
+// registryNamespacePrefixes is the namespace prefix added to single-segment
+// names when normalizing against a registry, keyed by domain. Registries
+// without an entry do not use a namespace prefix.
+var registryNamespacePrefixes = map[string]string{
+	defaultDomain: officialRepoPrefix,
+}
+
+// NormalizeFor normalizes s against the given registry (domain) instead of
+// Docker Hub. Single-segment names get the namespace prefix conventionally
+// used by the registry; only Docker Hub uses a prefix ("library/"), so, for
+// example, "app" is normalized to "docker.io/library/app" for "docker.io",
+// and to "ghcr.io/app" for "ghcr.io". If s includes a domain, registry is
+// ignored and s is normalized as with [ParseNormalizedNamed].
+func NormalizeFor(registry, s string) (Named, error) {
+	return normalizeFor(registry, s, isDomainComponent)
+}
+
+// normalizeFor is the implementation of [NormalizeFor], using isDomain to
+// decide whether the first component of s is a domain.
+func normalizeFor(registry, s string, isDomain func(component string) bool) (Named, error) {
+	if _, err := parseNormalizedNamed(s, isDomain); err != nil {
+		return nil, err
+	}
+	if i := strings.IndexRune(s, '/'); i != -1 && isDomain(s[:i]) {
+		return parseNormalizedNamed(s, isDomain)
+	}
+	if registry == legacyDefaultDomain {
+		registry = defaultDomain
+	}
+	if !anchoredDomainRegexp.MatchString(registry) {
+		return nil, fmt.Errorf("invalid registry domain %q: %w", registry, ErrReferenceInvalidFormat)
+	}
+	if prefix := registryNamespacePrefixes[registry]; !strings.ContainsRune(s, '/') {
+		s = prefix + s
+	}
+	ref, err := Parse(registry + "/" + s)
+	if err != nil {
+		return nil, err
+	}
+	return ref.(Named), nil
+}
+
 // familiarizeName returns a shortened version of the name familiar
 // to to the Docker UI. Familiar names have the default domain
 // "docker.io" and "library/" repository prefix removed.
@@ -194,6 +508,19 @@ func (c canonicalReference) Familiar() Named {
 	}
 }
 
+// ExplicitLibrary returns ref with the "library/" prefix spelled out for
+// official images on Docker Hub, which is the opposite of familiarization.
+// For a single-component path with no domain or a Docker Hub domain (such as
+// "nginx" or "index.docker.io/nginx"), it returns "docker.io/library/<name>",
+// preserving the tag and digest. Other references are returned unchanged.
+func ExplicitLibrary(ref Named) Named {
+	domain, path := CanonicalDomain(Domain(ref)), Path(ref)
+	if (domain != "" && domain != defaultDomain) || strings.ContainsRune(path, '/') {
+		return ref
+	}
+	return withRepository(ref, repository{domain: defaultDomain, path: officialRepoPrefix + path})
+}
+
 // TagNameOnly adds the default tag "latest" to a reference if it only has
 // a repo name.
 func TagNameOnly(ref Named) Named {
@@ -220,5 +547,95 @@ func ParseAnyReference(ref string) (Reference, error) {
 		return digestReference(dgst), nil
 	}
 
-	return ParseNormalizedNamed(ref)
+	return parseNormalized(ref)
+}
+
+// ImageID is the identifier of a local image, as returned by
+// [ParseAnyReferenceTyped] for a bare 64-character hexadecimal string (for
+// example, as printed by "docker images --no-trunc" without the algorithm).
+// It is a [Digested] reference with the "sha256" algorithm, but, unlike
+// other references, does not refer to content in a registry.
+type ImageID digest.Digest
+
+// String returns the identifier with its algorithm ("sha256:<hex>").
+func (id ImageID) String() string {
+	return string(id)
+}
+
+// Digest returns the identifier as a digest.
+func (id ImageID) Digest() digest.Digest {
+	return digest.Digest(id)
+}
+
+// ParseAnyReferenceTyped parses s in the same way as [ParseAnyReference], but
+// returns an [ImageID] if s is a bare 64-character hexadecimal string, so
+// that callers can tell image identifiers apart from digests and named
+// references using a type switch.
+func ParseAnyReferenceTyped(s string) (Reference, error) {
+	if anchoredIdentifierRegexp.MatchString(s) {
+		return ImageID("sha256:" + s), nil
+	}
+	return ParseAnyReference(s)
+}
+
+// TokenKind describes how [ParseAnyReference] interprets a string.
+type TokenKind int
+
+const (
+	// TokenInvalid is a string that is not a valid reference.
+	TokenInvalid TokenKind = iota
+
+	// TokenImageID is a 64-character hexadecimal string, which is
+	// interpreted as an identifier (the encoded part of a sha256 digest),
+	// for example, an image ID. It is never interpreted as a name.
+	TokenImageID
+
+	// TokenAlgoDigest is a digest including the algorithm, without a name,
+	// for example, "sha256:<encoded>".
+	TokenAlgoDigest
+
+	// TokenDigest is a name with a digest, and optionally a tag, for example,
+	// "redis@sha256:<encoded>".
+	TokenDigest
+
+	// TokenName is a name, optionally with a tag, for example, "redis" or
+	// "redis:latest". Hexadecimal strings that are not exactly 64 characters
+	// long, such as "dbcc1", are names.
+	TokenName
+)
+
+// String returns a human-readable description of the token kind.
+func (k TokenKind) String() string {
+	switch k {
+	case TokenImageID:
+		return "image ID"
+	case TokenAlgoDigest:
+		return "digest"
+	case TokenDigest:
+		return "digested reference"
+	case TokenName:
+		return "name"
+	default:
+		return "invalid"
+	}
+}
+
+// ClassifyBareToken returns how s would be interpreted by
+// [ParseAnyReference], allowing a caller to explain the interpretation to a
+// user before acting on it.
+func ClassifyBareToken(s string) TokenKind {
+	if anchoredIdentifierRegexp.MatchString(s) {
+		return TokenImageID
+	}
+	if _, err := digest.Parse(s); err == nil {
+		return TokenAlgoDigest
+	}
+	named, err := parseNormalized(s)
+	if err != nil {
+		return TokenInvalid
+	}
+	if _, ok := named.(Digested); ok {
+		return TokenDigest
+	}
+	return TokenName
 }
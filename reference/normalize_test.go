@@ -2,6 +2,8 @@ package reference
 
 import (
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/opencontainers/go-digest"
@@ -140,133 +142,141 @@ func TestValidateRemoteName(t *testing.T) {
 	}
 }
 
-func TestParseRepositoryInfo(t *testing.T) {
-	t.Parallel()
-	type tcase struct {
-		RemoteName, FamiliarName, FullName, AmbiguousName, Domain string
-	}
+// repositoryInfoTestcase holds the spellings of a repository, as used by
+// TestParseRepositoryInfo.
+type repositoryInfoTestcase struct {
+	RemoteName, FamiliarName, FullName, AmbiguousName, Domain string
+}
 
-	tcases := []tcase{
-		{
-			RemoteName:    "fooo/bar",
-			FamiliarName:  "fooo/bar",
-			FullName:      "docker.io/fooo/bar",
-			AmbiguousName: "index.docker.io/fooo/bar",
-			Domain:        "docker.io",
-		},
-		{
-			RemoteName:    "library/ubuntu",
-			FamiliarName:  "ubuntu",
-			FullName:      "docker.io/library/ubuntu",
-			AmbiguousName: "library/ubuntu",
-			Domain:        "docker.io",
-		},
-		{
-			RemoteName:    "nonlibrary/ubuntu",
-			FamiliarName:  "nonlibrary/ubuntu",
-			FullName:      "docker.io/nonlibrary/ubuntu",
-			AmbiguousName: "",
-			Domain:        "docker.io",
-		},
-		{
-			RemoteName:    "other/library",
-			FamiliarName:  "other/library",
-			FullName:      "docker.io/other/library",
-			AmbiguousName: "",
-			Domain:        "docker.io",
-		},
-		{
-			RemoteName:    "private/moonbase",
-			FamiliarName:  "127.0.0.1:8000/private/moonbase",
-			FullName:      "127.0.0.1:8000/private/moonbase",
-			AmbiguousName: "",
-			Domain:        "127.0.0.1:8000",
-		},
-		{
-			RemoteName:    "privatebase",
-			FamiliarName:  "127.0.0.1:8000/privatebase",
-			FullName:      "127.0.0.1:8000/privatebase",
-			AmbiguousName: "",
-			Domain:        "127.0.0.1:8000",
-		},
-		{
-			RemoteName:    "private/moonbase",
-			FamiliarName:  "example.com/private/moonbase",
-			FullName:      "example.com/private/moonbase",
-			AmbiguousName: "",
-			Domain:        "example.com",
-		},
-		{
-			RemoteName:    "privatebase",
-			FamiliarName:  "example.com/privatebase",
-			FullName:      "example.com/privatebase",
-			AmbiguousName: "",
-			Domain:        "example.com",
-		},
-		{
-			RemoteName:    "private/moonbase",
-			FamiliarName:  "example.com:8000/private/moonbase",
-			FullName:      "example.com:8000/private/moonbase",
-			AmbiguousName: "",
-			Domain:        "example.com:8000",
-		},
-		{
-			RemoteName:    "privatebasee",
-			FamiliarName:  "example.com:8000/privatebasee",
-			FullName:      "example.com:8000/privatebasee",
-			AmbiguousName: "",
-			Domain:        "example.com:8000",
-		},
-		{
-			RemoteName:    "library/ubuntu-12.04-base",
-			FamiliarName:  "ubuntu-12.04-base",
-			FullName:      "docker.io/library/ubuntu-12.04-base",
-			AmbiguousName: "index.docker.io/library/ubuntu-12.04-base",
-			Domain:        "docker.io",
-		},
-		{
-			RemoteName:    "library/foo",
-			FamiliarName:  "foo",
-			FullName:      "docker.io/library/foo",
-			AmbiguousName: "docker.io/foo",
-			Domain:        "docker.io",
-		},
-		{
-			RemoteName:    "library/foo/bar",
-			FamiliarName:  "library/foo/bar",
-			FullName:      "docker.io/library/foo/bar",
-			AmbiguousName: "",
-			Domain:        "docker.io",
-		},
-		{
-			RemoteName:    "store/foo/bar",
-			FamiliarName:  "store/foo/bar",
-			FullName:      "docker.io/store/foo/bar",
-			AmbiguousName: "",
-			Domain:        "docker.io",
-		},
-		{
-			RemoteName:    "bar",
-			FamiliarName:  "Foo/bar",
-			FullName:      "Foo/bar",
-			AmbiguousName: "",
-			Domain:        "Foo",
-		},
-		{
-			RemoteName:    "bar",
-			FamiliarName:  "FOO/bar",
-			FullName:      "FOO/bar",
-			AmbiguousName: "",
-			Domain:        "FOO",
-		},
+// refStrings returns the familiar, full, and ambiguous (if any) spellings.
+func (tc repositoryInfoTestcase) refStrings() []string {
+	refStrings := []string{tc.FamiliarName, tc.FullName}
+	if tc.AmbiguousName != "" {
+		refStrings = append(refStrings, tc.AmbiguousName)
 	}
+	return refStrings
+}
+
+var repositoryInfoTestcases = []repositoryInfoTestcase{
+	{
+		RemoteName:    "fooo/bar",
+		FamiliarName:  "fooo/bar",
+		FullName:      "docker.io/fooo/bar",
+		AmbiguousName: "index.docker.io/fooo/bar",
+		Domain:        "docker.io",
+	},
+	{
+		RemoteName:    "library/ubuntu",
+		FamiliarName:  "ubuntu",
+		FullName:      "docker.io/library/ubuntu",
+		AmbiguousName: "library/ubuntu",
+		Domain:        "docker.io",
+	},
+	{
+		RemoteName:    "nonlibrary/ubuntu",
+		FamiliarName:  "nonlibrary/ubuntu",
+		FullName:      "docker.io/nonlibrary/ubuntu",
+		AmbiguousName: "",
+		Domain:        "docker.io",
+	},
+	{
+		RemoteName:    "other/library",
+		FamiliarName:  "other/library",
+		FullName:      "docker.io/other/library",
+		AmbiguousName: "",
+		Domain:        "docker.io",
+	},
+	{
+		RemoteName:    "private/moonbase",
+		FamiliarName:  "127.0.0.1:8000/private/moonbase",
+		FullName:      "127.0.0.1:8000/private/moonbase",
+		AmbiguousName: "",
+		Domain:        "127.0.0.1:8000",
+	},
+	{
+		RemoteName:    "privatebase",
+		FamiliarName:  "127.0.0.1:8000/privatebase",
+		FullName:      "127.0.0.1:8000/privatebase",
+		AmbiguousName: "",
+		Domain:        "127.0.0.1:8000",
+	},
+	{
+		RemoteName:    "private/moonbase",
+		FamiliarName:  "example.com/private/moonbase",
+		FullName:      "example.com/private/moonbase",
+		AmbiguousName: "",
+		Domain:        "example.com",
+	},
+	{
+		RemoteName:    "privatebase",
+		FamiliarName:  "example.com/privatebase",
+		FullName:      "example.com/privatebase",
+		AmbiguousName: "",
+		Domain:        "example.com",
+	},
+	{
+		RemoteName:    "private/moonbase",
+		FamiliarName:  "example.com:8000/private/moonbase",
+		FullName:      "example.com:8000/private/moonbase",
+		AmbiguousName: "",
+		Domain:        "example.com:8000",
+	},
+	{
+		RemoteName:    "privatebasee",
+		FamiliarName:  "example.com:8000/privatebasee",
+		FullName:      "example.com:8000/privatebasee",
+		AmbiguousName: "",
+		Domain:        "example.com:8000",
+	},
+	{
+		RemoteName:    "library/ubuntu-12.04-base",
+		FamiliarName:  "ubuntu-12.04-base",
+		FullName:      "docker.io/library/ubuntu-12.04-base",
+		AmbiguousName: "index.docker.io/library/ubuntu-12.04-base",
+		Domain:        "docker.io",
+	},
+	{
+		RemoteName:    "library/foo",
+		FamiliarName:  "foo",
+		FullName:      "docker.io/library/foo",
+		AmbiguousName: "docker.io/foo",
+		Domain:        "docker.io",
+	},
+	{
+		RemoteName:    "library/foo/bar",
+		FamiliarName:  "library/foo/bar",
+		FullName:      "docker.io/library/foo/bar",
+		AmbiguousName: "",
+		Domain:        "docker.io",
+	},
+	{
+		RemoteName:    "store/foo/bar",
+		FamiliarName:  "store/foo/bar",
+		FullName:      "docker.io/store/foo/bar",
+		AmbiguousName: "",
+		Domain:        "docker.io",
+	},
+	{
+		RemoteName:    "bar",
+		FamiliarName:  "Foo/bar",
+		FullName:      "Foo/bar",
+		AmbiguousName: "",
+		Domain:        "Foo",
+	},
+	{
+		RemoteName:    "bar",
+		FamiliarName:  "FOO/bar",
+		FullName:      "FOO/bar",
+		AmbiguousName: "",
+		Domain:        "FOO",
+	},
+}
 
-	for i, tc := range tcases {
+func TestParseRepositoryInfo(t *testing.T) {
+	t.Parallel()
+	for i, tc := range repositoryInfoTestcases {
 		tc := tc
-		refStrings := []string{tc.FamiliarName, tc.FullName}
-		if tc.AmbiguousName != "" {
-			refStrings = append(refStrings, tc.AmbiguousName)
-		}
+		refStrings := tc.refStrings()
 
 		for _, r := range refStrings {
 			t.Run(strconv.Itoa(i)+"/"+r, func(t *testing.T) {
@@ -365,6 +375,16 @@ func equalReference(r1, r2 Reference) bool {
 	return false
 }
 
+// mustParseNormalized parses s with [ParseNormalizedNamed].
+func mustParseNormalized(t *testing.T, s string) Named {
+	t.Helper()
+	named, err := ParseNormalizedNamed(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return named
+}
+
 func TestParseAnyReference(t *testing.T) {
 	t.Parallel()
 	tcases := []struct {
@@ -536,6 +556,52 @@ func TestNormalizedSplitHostname(t *testing.T) {
 		if name != testcase.name {
 			failf("unexpected name: got %q, expected %q", name, testcase.name)
 		}
+		joined, err := JoinHostname(domain, name)
+		if err != nil {
+			failf("error joining name: %s", err)
+		} else if joined.String() != named.String() {
+			failf("unexpected joined name: got %q, expected %q", joined.String(), named.String())
+		}
+	}
+}
+
+func TestJoinHostname(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		domain   string
+		name     string
+		expected string
+		err      error
+	}{
+		{domain: "", name: "foo", expected: "docker.io/library/foo"},
+		{domain: "", name: "user/foo", expected: "docker.io/user/foo"},
+		{domain: "docker.io", name: "foo", expected: "docker.io/library/foo"},
+		{domain: "index.docker.io", name: "foo", expected: "docker.io/library/foo"},
+		{domain: "index.docker.io", name: "library/foo", expected: "docker.io/library/foo"},
+		{domain: "test.com", name: "foo", expected: "test.com/foo"},
+		{domain: "test.com:8080", name: "foo/bar", expected: "test.com:8080/foo/bar"},
+		{domain: "docker.io", name: "", err: ErrNameEmpty},
+		{domain: "test.com", name: "Foo", err: ErrReferenceInvalidFormat},
+		{domain: "test.com/foo", name: "bar", err: ErrReferenceInvalidFormat},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.domain+"/"+testcase.name, func(t *testing.T) {
+			t.Parallel()
+			named, err := JoinHostname(testcase.domain, testcase.name)
+			if testcase.err != nil {
+				if err != testcase.err {
+					t.Fatalf("unexpected error: got %v, expected %v", err, testcase.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if named.String() != testcase.expected {
+				t.Errorf("unexpected name: got %q, expected %q", named.String(), testcase.expected)
+			}
+		})
 	}
 }
 
@@ -701,3 +767,597 @@ func TestParseDockerRef(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAllNormalized(t *testing.T) {
+	t.Parallel()
+	refs, index, err := ParseAllNormalized([]string{"nginx", "example.com/app:1.0", "user/app"})
+	if err != nil || index != -1 {
+		t.Fatalf("unexpected result: index %d, error %v", index, err)
+	}
+	expected := []string{"docker.io/library/nginx", "example.com/app:1.0", "docker.io/user/app"}
+	if len(refs) != len(expected) {
+		t.Fatalf("unexpected references: got %q, expected %q", refs, expected)
+	}
+	for i, ref := range refs {
+		if ref.String() != expected[i] {
+			t.Errorf("unexpected reference at %d: got %q, expected %q", i, ref.String(), expected[i])
+		}
+	}
+
+	refs, index, err = ParseAllNormalized([]string{"nginx", "Invalid", "redis", "nginx:"})
+	if err == nil || index != 1 {
+		t.Fatalf("unexpected result: index %d, error %v", index, err)
+	}
+	if len(refs) != 1 || refs[0].String() != "docker.io/library/nginx" {
+		t.Errorf("unexpected references: %q", refs)
+	}
+
+	refs, index, err = ParseAllNormalized(nil)
+	if err != nil || index != -1 || len(refs) != 0 {
+		t.Errorf("unexpected result for empty input: %q, index %d, error %v", refs, index, err)
+	}
+}
+
+func TestNormalizationDiff(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input   string
+		full    string
+		changes []string
+	}{
+		{
+			input:   "nginx",
+			full:    "docker.io/library/nginx",
+			changes: []string{"added domain docker.io", "added library/ prefix"},
+		},
+		{
+			input:   "library/nginx:1.25",
+			full:    "docker.io/library/nginx:1.25",
+			changes: []string{"added domain docker.io"},
+		},
+		{
+			input:   "index.docker.io/nginx",
+			full:    "docker.io/library/nginx",
+			changes: []string{"replaced domain index.docker.io with docker.io", "added library/ prefix"},
+		},
+		{
+			input: "docker.io/user/app",
+			full:  "docker.io/user/app",
+		},
+		{
+			input: "example.com/app",
+			full:  "example.com/app",
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			full, changes, err := NormalizationDiff(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if full != testcase.full {
+				t.Errorf("unexpected full string: got %q, expected %q", full, testcase.full)
+			}
+			if strings.Join(changes, "\n") != strings.Join(testcase.changes, "\n") {
+				t.Errorf("unexpected changes: got %q, expected %q", changes, testcase.changes)
+			}
+		})
+	}
+	if _, _, err := NormalizationDiff("Nginx"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestExplainNormalization(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		expected string
+		notes    []string
+	}{
+		{
+			input:    "test_com/foo",
+			expected: "docker.io/test_com/foo",
+			notes: []string{
+				`"test_com" is treated as a path component instead of a domain because it contains no "." or ":" and is not "localhost"; using default domain "docker.io"`,
+			},
+		},
+		{
+			input:    "test.com/foo",
+			expected: "test.com/foo",
+			notes: []string{
+				`"test.com" is treated as a domain`,
+			},
+		},
+		{
+			input:    "foo",
+			expected: "docker.io/library/foo",
+			notes: []string{
+				`no domain specified, using default domain "docker.io"`,
+				`"library/" prefix added for official image`,
+			},
+		},
+		{
+			input:    "index.docker.io/foo:latest",
+			expected: "docker.io/library/foo:latest",
+			notes: []string{
+				`"index.docker.io" is treated as a domain`,
+				`legacy domain "index.docker.io" is replaced with "docker.io"`,
+				`"library/" prefix added for official image`,
+			},
+		},
+		{
+			input:    "docker.io/library/foo",
+			expected: "docker.io/library/foo",
+			notes: []string{
+				`"docker.io" is treated as a domain`,
+			},
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			named, notes, err := ExplainNormalization(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if named.String() != testcase.expected {
+				t.Errorf("unexpected reference: got %q, expected %q", named.String(), testcase.expected)
+			}
+			if len(notes) != len(testcase.notes) {
+				t.Fatalf("unexpected notes: got %q, expected %q", notes, testcase.notes)
+			}
+			for i := range notes {
+				if notes[i] != testcase.notes[i] {
+					t.Errorf("unexpected note %d: got %q, expected %q", i, notes[i], testcase.notes[i])
+				}
+			}
+		})
+	}
+
+	if _, _, err := ExplainNormalization("docker/Docker"); err == nil {
+		t.Error("expected error for invalid reference")
+	}
+}
+
+func TestHasExplicitDomain(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		expected bool
+	}{
+		{input: "nginx", expected: false},
+		{input: "nginx:latest", expected: false},
+		{input: "library/nginx", expected: false},
+		{input: "test_com/foo", expected: false},
+		{input: "docker.io/nginx", expected: true},
+		{input: "index.docker.io/library/nginx", expected: true},
+		{input: "localhost/foo", expected: true},
+		{input: "localhost:5000/foo", expected: true},
+		{input: "test.com/foo", expected: true},
+		{input: "Foo/bar", expected: true},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			actual, err := HasExplicitDomain(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != testcase.expected {
+				t.Errorf("expected %v, got %v", testcase.expected, actual)
+			}
+		})
+	}
+
+	if _, err := HasExplicitDomain("docker///docker"); err == nil {
+		t.Error("expected error for invalid reference")
+	}
+}
+
+func TestOfficialName(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "nginx", expected: "library/nginx"},
+		{input: "docker.io/library/nginx:1.25", expected: "library/nginx"},
+		{input: "index.docker.io/nginx", expected: "library/nginx"},
+		{input: "user/app"},
+		{input: "library/foo/bar"},
+		{input: "gcr.io/library/nginx"},
+		{input: "localhost:5000/nginx"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			name, ok := OfficialName(ref)
+			if name != testcase.expected || ok != (testcase.expected != "") {
+				t.Errorf("unexpected: got (%q, %v), expected %q", name, ok, testcase.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeFor(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		registry string
+		input    string
+		expected string
+		err      bool
+	}{
+		{registry: "docker.io", input: "nginx", expected: "docker.io/library/nginx"},
+		{registry: "index.docker.io", input: "nginx:1.25", expected: "docker.io/library/nginx:1.25"},
+		{registry: "docker.io", input: "user/app", expected: "docker.io/user/app"},
+		{registry: "ghcr.io", input: "app", expected: "ghcr.io/app"},
+		{registry: "ghcr.io", input: "org/app:v1", expected: "ghcr.io/org/app:v1"},
+		{registry: "registry.internal:5000", input: "app", expected: "registry.internal:5000/app"},
+		{registry: "ghcr.io", input: "quay.io/org/app", expected: "quay.io/org/app"},
+		{registry: "-invalid", input: "app", err: true},
+		{registry: "ghcr.io", input: "App", err: true},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.registry+"/"+testcase.input, func(t *testing.T) {
+			t.Parallel()
+			named, err := NormalizeFor(testcase.registry, testcase.input)
+			if testcase.err {
+				if err == nil {
+					t.Errorf("expected error, got %q", named.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if named.String() != testcase.expected {
+				t.Errorf("unexpected: got %q, expected %q", named.String(), testcase.expected)
+			}
+		})
+	}
+}
+
+func TestParseNormalizedNamedRaw(t *testing.T) {
+	t.Parallel()
+	const dgst = "sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+	testcases := []struct {
+		input      string
+		normalized string
+		original   string
+	}{
+		{
+			input:      "nginx",
+			normalized: "docker.io/library/nginx",
+			original:   "nginx",
+		},
+		{
+			input:      "nginx:1.25",
+			normalized: "docker.io/library/nginx:1.25",
+			original:   "nginx:1.25",
+		},
+		{
+			input:      "user/app@" + dgst,
+			normalized: "docker.io/user/app@" + dgst,
+			original:   "user/app@" + dgst,
+		},
+		{
+			input:      "index.docker.io/nginx:1.25",
+			normalized: "docker.io/library/nginx:1.25",
+			original:   "index.docker.io/nginx:1.25",
+		},
+		{
+			input:      "localhost:5000/app:1.25@" + dgst,
+			normalized: "localhost:5000/app:1.25@" + dgst,
+			original:   "localhost:5000/app:1.25@" + dgst,
+		},
+		{
+			input:      "docker.io/library/nginx:latest",
+			normalized: "docker.io/library/nginx:latest",
+			original:   "docker.io/library/nginx:latest",
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			normalized, original, err := ParseNormalizedNamedRaw(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if normalized.String() != testcase.normalized {
+				t.Errorf("unexpected normalized reference: got %q, expected %q", normalized.String(), testcase.normalized)
+			}
+			if original.String() != testcase.original {
+				t.Errorf("unexpected original reference: got %q, expected %q", original.String(), testcase.original)
+			}
+			_, normalizedTagged := normalized.(Tagged)
+			_, originalTagged := original.(Tagged)
+			if normalizedTagged != originalTagged {
+				t.Errorf("tag presence differs: normalized %v, original %v", normalizedTagged, originalTagged)
+			}
+		})
+	}
+
+	if _, _, err := ParseNormalizedNamedRaw("docker/Docker"); err == nil {
+		t.Error("expected error for invalid reference")
+	}
+}
+
+func TestClassifyBareToken(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		expected TokenKind
+	}{
+		{
+			input:    "dbcc1c35ac38df41fd2f5e4130b32ffdb93ebae8b3dbe638c23575912276fc9c",
+			expected: TokenImageID,
+		},
+		{
+			input:    "sha256:dbcc1c35ac38df41fd2f5e4130b32ffdb93ebae8b3dbe638c23575912276fc9c",
+			expected: TokenAlgoDigest,
+		},
+		{
+			input:    "redis@sha256:dbcc1c35ac38df41fd2f5e4130b32ffdb93ebae8b3dbe638c23575912276fc9c",
+			expected: TokenDigest,
+		},
+		{
+			input:    "dbcc1c35ac38df41fd2f5e4130b32ffdb93ebae8b3dbe638c23575912276fc9",
+			expected: TokenName,
+		},
+		{
+			input:    "dbcc1",
+			expected: TokenName,
+		},
+		{
+			input:    "redis:latest",
+			expected: TokenName,
+		},
+		{
+			input:    "docker/Docker",
+			expected: TokenInvalid,
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			kind := ClassifyBareToken(testcase.input)
+			if kind != testcase.expected {
+				t.Fatalf("expected %v, got %v", testcase.expected, kind)
+			}
+
+			// The classification must agree with ParseAnyReference.
+			ref, err := ParseAnyReference(testcase.input)
+			if (err != nil) != (kind == TokenInvalid) {
+				t.Fatalf("unexpected error from ParseAnyReference: %v", err)
+			}
+			_, isNamed := ref.(Named)
+			if isNamed != (kind == TokenDigest || kind == TokenName) {
+				t.Errorf("ParseAnyReference returned %T for %v", ref, kind)
+			}
+		})
+	}
+}
+
+// TestNormalizationHook is not parallel, as it modifies NormalizationHook,
+// which affects all tests parsing references.
+func TestNormalizationHook(t *testing.T) {
+	type call struct {
+		event string
+		input string
+	}
+	var (
+		mu    sync.Mutex
+		calls []call
+	)
+	NormalizationHook = func(event string, input string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, call{event: event, input: input})
+	}
+	defer func() { NormalizationHook = nil }()
+
+	testcases := []struct {
+		input    string
+		parse    func(string) (Named, error)
+		expected []string
+	}{
+		{
+			input:    "nginx",
+			parse:    ParseDockerRef,
+			expected: []string{EventDefaultDomain, EventLibraryPrefix, EventDefaultTag},
+		},
+		{
+			input:    "nginx",
+			parse:    ParseNormalizedNamed,
+			expected: []string{EventDefaultDomain, EventLibraryPrefix},
+		},
+		{
+			input:    "index.docker.io/nginx:1.25",
+			parse:    ParseDockerRef,
+			expected: []string{EventLegacyDomain, EventLibraryPrefix},
+		},
+		{
+			input:    "user/app:1.0",
+			parse:    ParseDockerRef,
+			expected: []string{EventDefaultDomain},
+		},
+		{
+			input: "docker.io/library/nginx:1.25",
+			parse: ParseDockerRef,
+		},
+		{
+			input:    "example.com/app",
+			parse:    ParseDockerRef,
+			expected: []string{EventDefaultTag},
+		},
+		{
+			input: "example.com/app",
+			parse: ParseNormalizedNamed,
+		},
+	}
+	for _, testcase := range testcases {
+		calls = nil
+		if _, err := testcase.parse(testcase.input); err != nil {
+			t.Fatal(err)
+		}
+		if len(calls) != len(testcase.expected) {
+			t.Errorf("%s: unexpected events: got %v, expected %v", testcase.input, calls, testcase.expected)
+			continue
+		}
+		for i, c := range calls {
+			if c.event != testcase.expected[i] || c.input != testcase.input {
+				t.Errorf("%s: unexpected call %d: got %v, expected %q", testcase.input, i, c, testcase.expected[i])
+			}
+		}
+	}
+
+	// Functions parsing references internally do not report events.
+	calls = nil
+	if _, err := NormalizeFor("ghcr.io", "app"); err != nil {
+		t.Fatal(err)
+	}
+	PortabilityWarnings("nginx")
+	FamiliarIsLossless(repository{domain: "docker.io", path: "library/nginx"})
+	if _, _, err := ParseAudit("nginx"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := HasExplicitDomain("nginx"); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("unexpected events for internal parsing: %v", calls)
+	}
+
+	// No events are reported for invalid input.
+	calls = nil
+	if _, err := ParseDockerRef("docker/Docker"); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(calls) != 0 {
+		t.Errorf("unexpected events for invalid input: %v", calls)
+	}
+}
+
+func TestExplicitLibrary(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    Named
+		expected string
+	}{
+		{input: repository{path: "nginx"}, expected: "docker.io/library/nginx"},
+		{input: reference{namedRepository: repository{path: "nginx"}, tag: "1.25"}, expected: "docker.io/library/nginx:1.25"},
+		{input: repository{domain: "docker.io", path: "nginx"}, expected: "docker.io/library/nginx"},
+		{input: repository{domain: "index.docker.io", path: "nginx"}, expected: "docker.io/library/nginx"},
+		{input: repository{domain: "docker.io", path: "library/nginx"}, expected: "docker.io/library/nginx"},
+		{input: repository{domain: "docker.io", path: "user/app"}, expected: "docker.io/user/app"},
+		{input: repository{path: "user/app"}, expected: "user/app"},
+		{input: repository{domain: "example.com", path: "nginx"}, expected: "example.com/nginx"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input.String(), func(t *testing.T) {
+			t.Parallel()
+			if actual := ExplicitLibrary(testcase.input).String(); actual != testcase.expected {
+				t.Errorf("unexpected reference: got %q, expected %q", actual, testcase.expected)
+			}
+		})
+	}
+
+	// Familiarization and ExplicitLibrary are inverses for official images.
+	named, err := ParseNormalizedNamed("nginx:1.25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := ExplicitLibrary(named.(normalizedNamed).Familiar()); actual.String() != named.String() {
+		t.Errorf("unexpected reference: got %q, expected %q", actual.String(), named.String())
+	}
+}
+
+func TestParseUnqualified(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input string
+		bare  bool
+		name  string
+	}{
+		{input: "nginx", bare: true, name: "nginx"},
+		{input: "nginx:1.25", bare: true, name: "nginx:1.25"},
+		{input: "library/nginx", bare: true, name: "library/nginx"},
+		{input: "user/app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", bare: true, name: "user/app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{input: "docker.io/nginx", bare: false, name: "nginx"},
+		{input: "localhost/foo:1.0", bare: false, name: "foo:1.0"},
+		{input: "example.com:5000/team/app", bare: false, name: "team/app"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			bare, name, err := ParseUnqualified(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bare != testcase.bare || name != testcase.name {
+				t.Errorf("unexpected result: got (%v, %q), expected (%v, %q)", bare, name, testcase.bare, testcase.name)
+			}
+		})
+	}
+	if _, _, err := ParseUnqualified("Nginx"); err == nil {
+		t.Error("expected error for invalid reference")
+	}
+}
+
+func TestParseAnyReferenceTyped(t *testing.T) {
+	t.Parallel()
+	const hex = "86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+	testcases := []struct {
+		input    string
+		kind     string
+		expected string
+	}{
+		{input: hex, kind: "id", expected: "sha256:" + hex},
+		{input: "sha256:" + hex, kind: "digest", expected: "sha256:" + hex},
+		{input: "nginx@sha256:" + hex, kind: "named", expected: "docker.io/library/nginx@sha256:" + hex},
+		{input: "nginx", kind: "named", expected: "docker.io/library/nginx"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseAnyReferenceTyped(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var kind string
+			switch r := ref.(type) {
+			case ImageID:
+				kind = "id"
+				if r.Digest().Encoded() != hex {
+					t.Errorf("unexpected encoded digest: %q", r.Digest().Encoded())
+				}
+			case Named:
+				kind = "named"
+			case Digested:
+				kind = "digest"
+			}
+			if kind != testcase.kind {
+				t.Errorf("unexpected kind: got %s (%T), expected %s", kind, ref, testcase.kind)
+			}
+			if ref.String() != testcase.expected {
+				t.Errorf("unexpected string: got %q, expected %q", ref.String(), testcase.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package reference
+
+// Normalizer parses familiar references into fully qualified references,
+// as done by [ParseNormalizedNamed], with customizable rules. The zero value
+// applies the same rules as [ParseNormalizedNamed].
+type Normalizer struct {
+	// IsDomain, if set, reports whether component, the first component of
+	// a familiar reference that is followed by a "/", is a domain rather
+	// than the first component of the path. It replaces the default rules,
+	// which treat a component as a domain if it contains a "." or ":", if it
+	// is "localhost", or if it contains uppercase characters. For example,
+	// it can be used to recognize internal registries that have hostnames
+	// without dots (such as "registry").
+	IsDomain func(component string) bool
+
+	// DefaultDomain, if set, is the domain of references without a domain,
+	// instead of "docker.io", as for [NormalizeFor]; for example, with
+	// "myreg.io", "foo" is parsed as "myreg.io/foo". The "library/" prefix
+	// is only added for Docker Hub. DefaultDomain is always recognized as a
+	// domain, regardless of IsDomain. An error is returned when parsing a
+	// reference without a domain if DefaultDomain is not a valid domain.
+	DefaultDomain string
+}
+
+// ParseNormalizedNamed parses s into a fully qualified reference, as done by
+// the package-level [ParseNormalizedNamed], using the rules of n.
+// [NormalizationHook] is not called.
+func (n Normalizer) ParseNormalizedNamed(s string) (Named, error) {
+	if n.DefaultDomain == "" {
+		return parseNormalizedNamed(s, n.isDomain)
+	}
+	return normalizeFor(n.DefaultDomain, s, func(component string) bool {
+		return component == n.DefaultDomain || n.isDomain(component)
+	})
+}
+
+// Equal reports whether a and b, parsed using the rules of n, are equal
+// according to [Equal]. For example, with the default domain "myreg.io",
+// "foo" and "myreg.io/foo" are equal. An error is returned if a or b is not
+// a valid reference.
+func (n Normalizer) Equal(a, b string) (bool, error) {
+	refA, err := n.ParseNormalizedNamed(a)
+	if err != nil {
+		return false, err
+	}
+	refB, err := n.ParseNormalizedNamed(b)
+	if err != nil {
+		return false, err
+	}
+	return Equal(refA, refB), nil
+}
+
+// isDomain calls n.IsDomain if set, or isDomainComponent otherwise.
+func (n Normalizer) isDomain(component string) bool {
+	if n.IsDomain != nil {
+		return n.IsDomain(component)
+	}
+	return isDomainComponent(component)
+}
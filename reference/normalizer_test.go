@@ -0,0 +1,173 @@
+package reference
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizer(t *testing.T) {
+	t.Parallel()
+	custom := Normalizer{
+		IsDomain: func(component string) bool {
+			return component == "registry" || strings.ContainsAny(component, ".:")
+		},
+	}
+	testcases := []struct {
+		input    string
+		expected string // with the default rules
+		custom   string // with the custom rules
+	}{
+		{
+			input:    "registry/team/app:1.0",
+			expected: "docker.io/registry/team/app:1.0",
+			custom:   "registry/team/app:1.0",
+		},
+		{
+			input:    "registry/app",
+			expected: "docker.io/registry/app",
+			custom:   "registry/app",
+		},
+		{
+			input:    "registry",
+			expected: "docker.io/library/registry",
+			custom:   "docker.io/library/registry",
+		},
+		{
+			input:    "example.com/app",
+			expected: "example.com/app",
+			custom:   "example.com/app",
+		},
+		{
+			input:    "localhost/app",
+			expected: "localhost/app",
+			custom:   "docker.io/localhost/app",
+		},
+		{
+			input:    "user/app",
+			expected: "docker.io/user/app",
+			custom:   "docker.io/user/app",
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			for _, tc := range []struct {
+				normalizer Normalizer
+				expected   string
+			}{
+				{normalizer: Normalizer{}, expected: testcase.expected},
+				{normalizer: custom, expected: testcase.custom},
+			} {
+				named, err := tc.normalizer.ParseNormalizedNamed(testcase.input)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if named.String() != tc.expected {
+					t.Errorf("unexpected reference: got %q, expected %q", named.String(), tc.expected)
+				}
+			}
+			if named, err := ParseNormalizedNamed(testcase.input); err != nil || named.String() != testcase.expected {
+				t.Errorf("zero Normalizer differs from ParseNormalizedNamed: got (%v, %v), expected %q", named, err, testcase.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizerDefaultDomain(t *testing.T) {
+	t.Parallel()
+	n := Normalizer{DefaultDomain: "myreg.io"}
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "foo", expected: "myreg.io/foo"},
+		{input: "foo:1.0", expected: "myreg.io/foo:1.0"},
+		{input: "team/foo", expected: "myreg.io/team/foo"},
+		{input: "myreg.io/foo", expected: "myreg.io/foo"},
+		{input: "docker.io/library/foo", expected: "docker.io/library/foo"},
+		{input: "docker.io/foo", expected: "docker.io/library/foo"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			named, err := n.ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if named.String() != testcase.expected {
+				t.Errorf("unexpected reference: got %q, expected %q", named.String(), testcase.expected)
+			}
+		})
+	}
+
+	// The default domain is applied in the same way as by NormalizeFor.
+	for _, input := range []string{"foo", "team/foo:1.0", "example.com/foo", "docker.io/foo"} {
+		for _, domain := range []string{"myreg.io", "docker.io", "index.docker.io"} {
+			named, err := Normalizer{DefaultDomain: domain}.ParseNormalizedNamed(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := NormalizeFor(domain, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if named.String() != expected.String() {
+				t.Errorf("%q with %q: got %q, NormalizeFor returned %q", input, domain, named.String(), expected.String())
+			}
+		}
+	}
+
+	// An invalid default domain is reported as such.
+	_, err := Normalizer{DefaultDomain: "my_reg.io"}.ParseNormalizedNamed("foo")
+	if err == nil || !strings.Contains(err.Error(), "invalid registry domain") || !errors.Is(err, ErrReferenceInvalidFormat) {
+		t.Errorf("unexpected error for invalid default domain: %v", err)
+	}
+
+	// The default domain is recognized even if IsDomain rejects it.
+	strict := Normalizer{DefaultDomain: "registry", IsDomain: func(string) bool { return false }}
+	for _, input := range []string{"foo", "registry/foo"} {
+		named, err := strict.ParseNormalizedNamed(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "registry/foo"; named.String() != expected {
+			t.Errorf("%q: unexpected reference: got %q, expected %q", input, named.String(), expected)
+		}
+	}
+}
+
+func TestNormalizerEqual(t *testing.T) {
+	t.Parallel()
+	custom := Normalizer{DefaultDomain: "myreg.io"}
+	testcases := []struct {
+		a, b       string
+		normalizer Normalizer
+		expected   bool
+	}{
+		{a: "foo", b: "myreg.io/foo", normalizer: custom, expected: true},
+		{a: "foo:1.0", b: "myreg.io/foo:1.0", normalizer: custom, expected: true},
+		{a: "foo", b: "docker.io/library/foo", normalizer: custom, expected: false},
+		{a: "foo", b: "myreg.io/foo", expected: false},
+		{a: "foo", b: "docker.io/library/foo", expected: true},
+		{a: "foo", b: "index.docker.io/foo", expected: true},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.a+"="+testcase.b, func(t *testing.T) {
+			t.Parallel()
+			equal, err := testcase.normalizer.Equal(testcase.a, testcase.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if equal != testcase.expected {
+				t.Errorf("unexpected result: got %v, expected %v", equal, testcase.expected)
+			}
+		})
+	}
+	if _, err := custom.Equal("foo", "Foo"); err == nil {
+		t.Error("expected error for invalid reference")
+	}
+}
@@ -0,0 +1,124 @@
+package reference
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// AnnotationRefName is the OCI image annotation holding the reference name
+// of an image in an image index, which may be a tag or a full reference.
+const AnnotationRefName = "org.opencontainers.image.ref.name"
+
+// ParseAnnotationRef returns the reference described by the value of an
+// [AnnotationRefName] annotation for an image in the repository with the
+// given domain and path. A bare tag (for example, "1.25") is combined with the
+// domain and path. Any other value is parsed as a full reference (in familiar
+// or fully qualified form), and an error is returned if it does not refer to
+// the repository with the given domain and path.
+func ParseAnnotationRef(domain, path, annotation string) (Named, error) {
+	name, err := WithName(domain + "/" + path)
+	if err != nil {
+		return nil, err
+	}
+	if anchoredTagRegexp.MatchString(annotation) {
+		return WithTag(name, annotation)
+	}
+
+	ref, err := parseNormalized(annotation)
+	if err != nil {
+		return nil, err
+	}
+	if Domain(ref) != Domain(name) || Path(ref) != Path(name) {
+		return nil, fmt.Errorf("reference %q in annotation does not match repository %q", annotation, name.Name())
+	}
+	return ref, nil
+}
+
+// OCILayoutRepository is the repository used by [ParseOCILayoutIndex] for
+// images in an OCI image layout that are only identified by a tag, as the
+// layout itself is the repository, and has no name of its own.
+const OCILayoutRepository = "localhost/oci-layout"
+
+// ociIndex is the subset of an OCI image index used by ParseOCILayoutIndex.
+type ociIndex struct {
+	Manifests []struct {
+		Annotations map[string]string `json:"annotations"`
+	} `json:"manifests"`
+}
+
+// AnnotationError describes an [AnnotationRefName] annotation that could not
+// be parsed as a reference.
+type AnnotationError struct {
+	// Index is the index of the manifest in the image index.
+	Index int
+
+	// Annotation is the value of the annotation.
+	Annotation string
+
+	// Err is the error returned by the parser.
+	Err error
+}
+
+func (e AnnotationError) Error() string {
+	return fmt.Sprintf("manifest %d: %q: %v", e.Index, e.Annotation, e.Err)
+}
+
+// Unwrap returns the underlying parse error.
+func (e AnnotationError) Unwrap() error {
+	return e.Err
+}
+
+// AnnotationErrors is a list of [AnnotationError], ordered by index.
+type AnnotationErrors []AnnotationError
+
+func (e AnnotationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ParseOCILayoutIndex reads the "index.json" file of an OCI image layout
+// from r, and returns the references in the [AnnotationRefName] annotations
+// of its manifests, in order. Manifests without the annotation are skipped.
+// Annotations holding a bare tag (for example, "1.25") are scoped to the
+// layout, using [OCILayoutRepository] as repository; other annotations are
+// parsed as full references (in familiar or fully qualified form).
+//
+// Annotations that cannot be parsed do not stop parsing; they are reported in
+// an error of type [AnnotationErrors], which is returned along with the
+// references that were parsed successfully. An error is returned without
+// references if r does not hold a valid JSON document.
+func ParseOCILayoutIndex(r io.Reader) ([]Named, error) {
+	var index ociIndex
+	if err := json.NewDecoder(r).Decode(&index); err != nil {
+		return nil, fmt.Errorf("invalid OCI image index: %w", err)
+	}
+	var (
+		refs []Named
+		errs AnnotationErrors
+	)
+	for i, manifest := range index.Manifests {
+		annotation, ok := manifest.Annotations[AnnotationRefName]
+		if !ok {
+			continue
+		}
+		s := annotation
+		if anchoredTagRegexp.MatchString(annotation) {
+			s = OCILayoutRepository + ":" + annotation
+		}
+		ref, err := parseNormalized(s)
+		if err != nil {
+			errs = append(errs, AnnotationError{Index: i, Annotation: annotation, Err: err})
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	if len(errs) > 0 {
+		return refs, errs
+	}
+	return refs, nil
+}
@@ -0,0 +1,159 @@
+package reference
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAnnotationRef(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		domain     string
+		path       string
+		annotation string
+		expected   string
+		err        bool
+	}{
+		{
+			domain:     "example.com",
+			path:       "team/app",
+			annotation: "1.25",
+			expected:   "example.com/team/app:1.25",
+		},
+		{
+			domain:     "docker.io",
+			path:       "library/nginx",
+			annotation: "latest",
+			expected:   "docker.io/library/nginx:latest",
+		},
+		{
+			domain:     "example.com",
+			path:       "team/app",
+			annotation: "example.com/team/app:1.25",
+			expected:   "example.com/team/app:1.25",
+		},
+		{
+			domain:     "docker.io",
+			path:       "library/nginx",
+			annotation: "nginx:1.25@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+			expected:   "docker.io/library/nginx:1.25@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+		},
+		{
+			domain:     "example.com",
+			path:       "team/app",
+			annotation: "example.com/team/other:1.25",
+			err:        true,
+		},
+		{
+			domain:     "example.com",
+			path:       "team/app",
+			annotation: "other.example.com/team/app:1.25",
+			err:        true,
+		},
+		{
+			domain:     "example.com",
+			path:       "team/app",
+			annotation: "-invalid",
+			err:        true,
+		},
+		{
+			domain:     "example.com",
+			path:       "Team/app",
+			annotation: "1.25",
+			err:        true,
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.annotation, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseAnnotationRef(testcase.domain, testcase.path, testcase.annotation)
+			if testcase.err {
+				if err == nil {
+					t.Errorf("expected error, got %q", ref.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ref.String() != testcase.expected {
+				t.Errorf("unexpected: got %q, expected %q", ref.String(), testcase.expected)
+			}
+		})
+	}
+}
+
+func TestParseOCILayoutIndex(t *testing.T) {
+	t.Parallel()
+	const index = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "manifests": [
+    {
+      "mediaType": "application/vnd.oci.image.manifest.v1+json",
+      "digest": "sha256:1111111111111111111111111111111111111111111111111111111111111111",
+      "size": 7143,
+      "annotations": {
+        "org.opencontainers.image.ref.name": "1.25"
+      }
+    },
+    {
+      "mediaType": "application/vnd.oci.image.manifest.v1+json",
+      "digest": "sha256:2222222222222222222222222222222222222222222222222222222222222222",
+      "size": 7143,
+      "annotations": {
+        "org.opencontainers.image.ref.name": "docker.io/library/nginx:1.26"
+      }
+    },
+    {
+      "mediaType": "application/vnd.oci.image.manifest.v1+json",
+      "digest": "sha256:3333333333333333333333333333333333333333333333333333333333333333",
+      "size": 7143
+    },
+    {
+      "mediaType": "application/vnd.oci.image.manifest.v1+json",
+      "digest": "sha256:4444444444444444444444444444444444444444444444444444444444444444",
+      "size": 7143,
+      "annotations": {
+        "org.opencontainers.image.ref.name": "Invalid/Reference:"
+      }
+    },
+    {
+      "mediaType": "application/vnd.oci.image.manifest.v1+json",
+      "digest": "sha256:5555555555555555555555555555555555555555555555555555555555555555",
+      "size": 7143,
+      "annotations": {
+        "org.opencontainers.image.ref.name": "example.com/app:latest",
+        "org.opencontainers.image.created": "2024-01-01T00:00:00Z"
+      }
+    }
+  ]
+}`
+	refs, err := ParseOCILayoutIndex(strings.NewReader(index))
+	expected := []string{"localhost/oci-layout:1.25", "docker.io/library/nginx:1.26", "example.com/app:latest"}
+	var actual []string
+	for _, ref := range refs {
+		actual = append(actual, ref.String())
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected references: got %q, expected %q", actual, expected)
+	}
+
+	var errs AnnotationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(errs) != 1 || errs[0].Index != 3 || errs[0].Annotation != "Invalid/Reference:" {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+
+	if refs, err := ParseOCILayoutIndex(strings.NewReader(`{"manifests": [`)); err == nil {
+		t.Errorf("expected error for invalid JSON, got %v", refs)
+	}
+	if refs, err := ParseOCILayoutIndex(strings.NewReader(`{"manifests": []}`)); err != nil || len(refs) != 0 {
+		t.Errorf("unexpected result for empty index: %v, %v", refs, err)
+	}
+}
@@ -0,0 +1,55 @@
+package reference
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// platformRegexp matches a platform suffix in the "os/arch[/variant]" form.
+var platformRegexp = regexp.MustCompile(anchored(capture(alphanumeric), `/`, capture(`[a-z0-9_]+`), optional(`/`, capture(alphanumeric))))
+
+// Platform describes the platform of an image, as specified in a platform
+// suffix accepted by [ParseWithPlatform].
+type Platform struct {
+	OS      string
+	Arch    string
+	Variant string
+}
+
+// String returns the platform in the "os/arch[/variant]" form.
+func (p Platform) String() string {
+	if p.Variant == "" {
+		return p.OS + "/" + p.Arch
+	}
+	return p.OS + "/" + p.Arch + "/" + p.Variant
+}
+
+// ParseWithPlatform parses s in the same way as [ParseNormalizedNamed],
+// additionally accepting a non-standard platform suffix, as written by some
+// tools. The platform suffix is separated from the reference by an "@", and
+// has the "os/arch[/variant]" form, for example:
+//
+//	nginx:1.25@linux/amd64
+//	nginx@sha256:<encoded>@linux/arm64/v8
+//
+// A suffix after an "@" is only treated as a platform if it does not contain
+// a ":", so it cannot be confused with a digest. The reference without the
+// platform suffix is returned along with the parsed platform. If s has no
+// platform suffix, it is parsed as-is and the returned platform is nil.
+func ParseWithPlatform(s string) (Named, *Platform, error) {
+	i := strings.LastIndexByte(s, '@')
+	if i == -1 || strings.ContainsRune(s[i+1:], ':') || !strings.ContainsRune(s[i+1:], '/') {
+		named, err := parseNormalized(s)
+		return named, nil, err
+	}
+	match := platformRegexp.FindStringSubmatch(s[i+1:])
+	if match == nil {
+		return nil, nil, fmt.Errorf("invalid platform %q: must be in the os/arch[/variant] form", s[i+1:])
+	}
+	named, err := parseNormalized(s[:i])
+	if err != nil {
+		return nil, nil, err
+	}
+	return named, &Platform{OS: match[1], Arch: match[2], Variant: match[3]}, nil
+}
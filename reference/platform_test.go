@@ -0,0 +1,79 @@
+package reference
+
+import (
+	"testing"
+)
+
+func TestParseWithPlatform(t *testing.T) {
+	t.Parallel()
+	const dgst = "sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+	testcases := []struct {
+		input    string
+		expected string
+		platform *Platform
+		err      bool
+	}{
+		{
+			input:    "nginx:1.25@linux/amd64",
+			expected: "docker.io/library/nginx:1.25",
+			platform: &Platform{OS: "linux", Arch: "amd64"},
+		},
+		{
+			input:    "example.com/app@" + dgst + "@linux/arm64/v8",
+			expected: "example.com/app@" + dgst,
+			platform: &Platform{OS: "linux", Arch: "arm64", Variant: "v8"},
+		},
+		{
+			input:    "example.com:5000/app@windows/amd64",
+			expected: "example.com:5000/app",
+			platform: &Platform{OS: "windows", Arch: "amd64"},
+		},
+		{
+			input:    "nginx:1.25",
+			expected: "docker.io/library/nginx:1.25",
+		},
+		{
+			input:    "nginx:1.25@" + dgst,
+			expected: "docker.io/library/nginx:1.25@" + dgst,
+		},
+		{
+			input: "nginx:1.25@linux/AMD64",
+			err:   true,
+		},
+		{
+			input: "nginx:1.25@linux/amd64/v8/extra",
+			err:   true,
+		},
+		{
+			input: "docker/Docker@linux/amd64",
+			err:   true,
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			named, platform, err := ParseWithPlatform(testcase.input)
+			if testcase.err {
+				if err == nil {
+					t.Errorf("expected error, got %q", named.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if named.String() != testcase.expected {
+				t.Errorf("unexpected reference: got %q, expected %q", named.String(), testcase.expected)
+			}
+			switch {
+			case testcase.platform == nil && platform != nil:
+				t.Errorf("unexpected platform %v", platform)
+			case testcase.platform != nil && platform == nil:
+				t.Errorf("expected platform %v", testcase.platform)
+			case testcase.platform != nil && *platform != *testcase.platform:
+				t.Errorf("unexpected platform: got %v, expected %v", platform, testcase.platform)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package reference
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrNonASCII is returned when a reference contains non-ASCII characters.
+var ErrNonASCII = errors.New("reference must only contain ASCII characters")
+
+// ASCIIOnly returns an error matching [ErrNonASCII] if the domain or path of
+// ref contains non-ASCII characters. References parsed by this package are
+// always ASCII, but ref may be a [Named] implementation that does not enforce
+// the reference grammar, for example, one holding an internationalized
+// domain name that was not converted to its punycode ("xn--") form.
+func ASCIIOnly(ref Named) error {
+	for _, component := range []struct {
+		name  string
+		value string
+	}{
+		{name: "domain", value: Domain(ref)},
+		{name: "path", value: Path(ref)},
+	} {
+		for i := 0; i < len(component.value); i++ {
+			if component.value[i] >= utf8.RuneSelf {
+				return fmt.Errorf("%w: %s %q", ErrNonASCII, component.name, component.value)
+			}
+		}
+	}
+	return nil
+}
+
+// ErrRegistryRequired is returned by [ParseRequireExplicitRegistry] for
+// references without a domain.
+var ErrRegistryRequired = errors.New("reference must include a registry domain")
+
+// ParseRequireExplicitRegistry parses s in the same way as
+// [ParseNormalizedNamed], but returns an error matching [ErrRegistryRequired]
+// if s has no domain and would therefore resolve to Docker Hub (see
+// [HasExplicitDomain]). It is intended for environments where references
+// must never implicitly resolve to Docker Hub, such as air-gapped networks;
+// "docker.io/nginx" is accepted, as the domain is explicit.
+func ParseRequireExplicitRegistry(s string) (Named, error) {
+	named, err := parseNormalized(s)
+	if err != nil {
+		return nil, err
+	}
+	if i := strings.IndexRune(s, '/'); i == -1 || !isDomainComponent(s[:i]) {
+		return nil, fmt.Errorf("%w: %q would resolve to %s", ErrRegistryRequired, s, defaultDomain)
+	}
+	return named, nil
+}
+
+// ErrPathTooDeep is returned by [CheckPathDepth] for references with more
+// path segments than allowed.
+var ErrPathTooDeep = errors.New("repository path has too many segments")
+
+// MaxPathDepth returns the number of segments of the path of ref, for
+// example, 2 for "docker.io/library/nginx" and 3 for
+// "example.com/team/project/app". The domain is not counted.
+func MaxPathDepth(ref Named) int {
+	return strings.Count(Path(ref), "/") + 1
+}
+
+// CheckPathDepth returns an error matching [ErrPathTooDeep] if the path of
+// ref has more than max segments (see [MaxPathDepth]), as some registries
+// limit the depth of repository paths.
+func CheckPathDepth(ref Named, max int) error {
+	if depth := MaxPathDepth(ref); depth > max {
+		return fmt.Errorf("%w: %q has %d segments, the maximum is %d", ErrPathTooDeep, Path(ref), depth, max)
+	}
+	return nil
+}
+
+// ErrPolicyViolation is returned by [Policy.Validate] for references that
+// are not allowed by the policy.
+var ErrPolicyViolation = errors.New("reference violates policy")
+
+// regexpRulePrefix is the prefix of policy rules that are regular
+// expressions instead of glob patterns.
+const regexpRulePrefix = "regexp:"
+
+// Policy is a set of rules that references must conform to, such as "only
+// images from internal.example.com/team-*". Use [NewPolicy] to create a
+// Policy.
+type Policy struct {
+	allow []policyRule
+	deny  []policyRule
+}
+
+// policyRule is a compiled rule of a Policy.
+type policyRule struct {
+	rule  string
+	match func(ref Named) bool
+}
+
+// NewPolicy returns a policy that allows references matching any of the
+// allow rules, unless they match any of the deny rules. If there are no
+// allow rules, all references that do not match a deny rule are allowed.
+//
+// A rule is either a glob pattern, matched as by [FamiliarMatch] (for
+// example, "internal.example.com/team-*/*"), or a regular expression
+// prefixed with "regexp:", matched against the whole fully qualified
+// reference (for example, "regexp:internal\.example\.com/team-[a-z]+/.+").
+// An error is returned if any of the rules is invalid.
+func NewPolicy(allow, deny []string) (*Policy, error) {
+	allowRules, err := compilePolicyRules(allow)
+	if err != nil {
+		return nil, err
+	}
+	denyRules, err := compilePolicyRules(deny)
+	if err != nil {
+		return nil, err
+	}
+	return &Policy{allow: allowRules, deny: denyRules}, nil
+}
+
+// compilePolicyRules compiles the rules of a Policy.
+func compilePolicyRules(rules []string) ([]policyRule, error) {
+	compiled := make([]policyRule, 0, len(rules))
+	for _, rule := range rules {
+		r, err := compilePolicyRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, r)
+	}
+	return compiled, nil
+}
+
+// compilePolicyRule compiles a rule of a Policy.
+func compilePolicyRule(rule string) (policyRule, error) {
+	if strings.HasPrefix(rule, regexpRulePrefix) {
+		re, err := regexp.Compile(anchored("(?:" + strings.TrimPrefix(rule, regexpRulePrefix) + ")"))
+		if err != nil {
+			return policyRule{}, fmt.Errorf("invalid policy rule %q: %w", rule, err)
+		}
+		return policyRule{rule: rule, match: func(ref Named) bool { return re.MatchString(ref.String()) }}, nil
+	}
+	if _, err := path.Match(rule, ""); err != nil {
+		return policyRule{}, fmt.Errorf("invalid policy rule %q: %w", rule, err)
+	}
+	return policyRule{rule: rule, match: func(ref Named) bool {
+		matched, _ := FamiliarMatch(rule, ref)
+		return matched
+	}}, nil
+}
+
+// Validate returns an error matching [ErrPolicyViolation] if ref is not
+// allowed by the policy. The error describes the rule that denied ref, or
+// that no allow rule matched.
+func (p *Policy) Validate(ref Named) error {
+	for _, rule := range p.deny {
+		if rule.match(ref) {
+			return fmt.Errorf("%w: %s is denied by rule %q", ErrPolicyViolation, FamiliarString(ref), rule.rule)
+		}
+	}
+	if len(p.allow) == 0 {
+		return nil
+	}
+	for _, rule := range p.allow {
+		if rule.match(ref) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not allowed by any rule", ErrPolicyViolation, FamiliarString(ref))
+}
+
+// ValidateK8sImage validates s as the image of a Kubernetes container, for
+// example, in an admission webhook. The image is parsed by [ParseDockerRef],
+// as done by the container runtime, and additionally rejected if:
+//
+//   - it is empty, as the image of a container is required;
+//   - it has leading or trailing whitespace, which the Kubernetes API server
+//     rejects, although such images would otherwise be parsed after trimming
+//     by some tools.
+//
+// This is a subset of the validation done by Kubernetes, covering the image
+// string only; validation that depends on other fields (such as the
+// ImagePullPolicy) is not performed.
+func ValidateK8sImage(s string) error {
+	if s == "" {
+		return errors.New("invalid Kubernetes image: image is required")
+	}
+	if strings.TrimSpace(s) != s {
+		return fmt.Errorf("invalid Kubernetes image %q: must not have leading or trailing whitespace", s)
+	}
+	if _, err := ParseDockerRef(s); err != nil {
+		return fmt.Errorf("invalid Kubernetes image %q: %w", s, err)
+	}
+	return nil
+}
+
+// ErrRegistryReserved is returned by the validators returned by
+// [RejectRegistries] for references on a reserved registry.
+var ErrRegistryReserved = errors.New("registry is reserved")
+
+// RejectRegistries returns a function that returns an error matching
+// [ErrRegistryReserved] if the canonical domain of a reference (see
+// [CanonicalDomain]) is one of reserved, and nil otherwise. References
+// without a domain are on Docker Hub ("docker.io"). Domains are compared
+// case-insensitively, including their port; reserved is copied, so that it
+// can be modified after the call.
+//
+// The returned function can be used to reject references to registries that
+// are blocked in an environment, right after parsing:
+//
+//	validate := reference.RejectRegistries([]string{"docker.io"})
+//	if err := validate(named); err != nil {
+//		return err
+//	}
+func RejectRegistries(reserved []string) func(Named) error {
+	domains := make(map[string]struct{}, len(reserved))
+	for _, domain := range reserved {
+		domains[strings.ToLower(CanonicalDomain(domain))] = struct{}{}
+	}
+	return func(ref Named) error {
+		domain, _ := canonicalRepository(Domain(ref), Path(ref))
+		if _, ok := domains[strings.ToLower(domain)]; ok {
+			return fmt.Errorf("%w: %s", ErrRegistryReserved, domain)
+		}
+		return nil
+	}
+}
+
+// ErrComponentTooLong is returned by [CheckLimits] for references with a
+// domain, path component, or tag that is longer than allowed.
+var ErrComponentTooLong = errors.New("reference component is too long")
+
+// CheckLimits checks the length of each component of ref against its limit:
+// [DomainMaxLength] for the domain, [PathComponentMaxLength] for each path
+// component, and [TagMaxLength] for the tag (if any). An error matching
+// [ErrComponentTooLong] is returned for the first component that exceeds its
+// limit, and [ErrNameTooLong] is returned if the name as a whole is longer
+// than [NameTotalMaxLength].
+//
+// References parsed by this package are always within these limits; the
+// check is useful for [Named] implementations that do not enforce them, and
+// for reporting which component caused a reference to be rejected.
+func CheckLimits(ref Named) error {
+	if domain := Domain(ref); len(domain) > DomainMaxLength {
+		return fmt.Errorf("%w: domain has %d characters, must not be more than %d", ErrComponentTooLong, len(domain), DomainMaxLength)
+	}
+	for _, component := range strings.Split(Path(ref), "/") {
+		if len(component) > PathComponentMaxLength {
+			return fmt.Errorf("%w: path component has %d characters, must not be more than %d", ErrComponentTooLong, len(component), PathComponentMaxLength)
+		}
+	}
+	if tagged, ok := ref.(Tagged); ok && len(tagged.Tag()) > TagMaxLength {
+		return fmt.Errorf("%w: tag has %d characters, must not be more than %d", ErrComponentTooLong, len(tagged.Tag()), TagMaxLength)
+	}
+	if len(ref.Name()) > NameTotalMaxLength {
+		return ErrNameTooLong
+	}
+	return nil
+}
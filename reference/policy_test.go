@@ -0,0 +1,332 @@
+package reference
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestASCIIOnly(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name string
+		ref  Named
+		err  bool
+	}{
+		{
+			name: "ascii",
+			ref:  repository{domain: "docker.io", path: "library/nginx"},
+		},
+		{
+			name: "punycode domain",
+			ref:  repository{domain: "xn--n3h.com", path: "foo"},
+		},
+		{
+			name: "unicode domain",
+			ref:  repository{domain: "☃.com", path: "foo"},
+			err:  true,
+		},
+		{
+			name: "cyrillic domain",
+			ref:  repository{domain: "dockеr.io", path: "library/nginx"},
+			err:  true,
+		},
+		{
+			name: "unicode path",
+			ref:  repository{domain: "example.com", path: "café"},
+			err:  true,
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.name, func(t *testing.T) {
+			t.Parallel()
+			err := ASCIIOnly(testcase.ref)
+			if testcase.err {
+				if !errors.Is(err, ErrNonASCII) {
+					t.Errorf("expected %v, got %v", ErrNonASCII, err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseRequireExplicitRegistry(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		expected string
+		err      error
+	}{
+		{input: "nginx", err: ErrRegistryRequired},
+		{input: "library/nginx:1.25", err: ErrRegistryRequired},
+		{input: "test_com/foo", err: ErrRegistryRequired},
+		{input: "myregistry.io/nginx", expected: "myregistry.io/nginx"},
+		{input: "localhost/foo", expected: "localhost/foo"},
+		{input: "localhost:5000/foo:1.0", expected: "localhost:5000/foo:1.0"},
+		{input: "docker.io/nginx", expected: "docker.io/library/nginx"},
+		{input: "myregistry.io/Nginx", err: ErrReferenceInvalidFormat},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			named, err := ParseRequireExplicitRegistry(testcase.input)
+			if testcase.err != nil {
+				if err == nil {
+					t.Fatalf("expected error, got %q", named.String())
+				}
+				if testcase.err == ErrRegistryRequired && !errors.Is(err, ErrRegistryRequired) {
+					t.Fatalf("unexpected error: got %v, expected %v", err, testcase.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if named.String() != testcase.expected {
+				t.Errorf("unexpected reference: got %q, expected %q", named.String(), testcase.expected)
+			}
+		})
+	}
+}
+
+func TestCheckPathDepth(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input string
+		depth int
+	}{
+		{input: "example.com/app", depth: 1},
+		{input: "nginx", depth: 2},
+		{input: "example.com/team/app:1.0", depth: 2},
+		{input: "example.com:5000/team/project/app", depth: 3},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			named, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if depth := MaxPathDepth(named); depth != testcase.depth {
+				t.Errorf("unexpected depth: got %d, expected %d", depth, testcase.depth)
+			}
+			if err := CheckPathDepth(named, testcase.depth); err != nil {
+				t.Errorf("unexpected error at the limit: %v", err)
+			}
+			if err := CheckPathDepth(named, testcase.depth-1); !errors.Is(err, ErrPathTooDeep) {
+				t.Errorf("unexpected error over the limit: got %v, expected %v", err, ErrPathTooDeep)
+			}
+		})
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	t.Parallel()
+	policy, err := NewPolicy(
+		[]string{"internal.example.com/team-*/*", `regexp:docker\.io/library/(nginx|redis)(:.*)?`},
+		[]string{"internal.example.com/team-legacy/*", "*:latest"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testcases := []struct {
+		input   string
+		allowed bool
+	}{
+		{input: "internal.example.com/team-a/app:1.0", allowed: true},
+		{input: "internal.example.com/team-b/tool@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", allowed: true},
+		{input: "internal.example.com/team-legacy/app:1.0", allowed: false},
+		{input: "internal.example.com/other/app:1.0", allowed: false},
+		{input: "internal.example.com/team-a/nested/app:1.0", allowed: false},
+		{input: "nginx:1.25", allowed: true},
+		{input: "redis", allowed: true},
+		{input: "nginx:latest", allowed: false},
+		{input: "postgres:16", allowed: false},
+		{input: "evil.example.com/internal.example.com/team-a/app:1.0", allowed: false},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			named, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = policy.Validate(named)
+			if testcase.allowed {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if !errors.Is(err, ErrPolicyViolation) {
+				t.Errorf("unexpected error: got %v, expected %v", err, ErrPolicyViolation)
+			}
+		})
+	}
+}
+
+func TestPolicyDenyOnly(t *testing.T) {
+	t.Parallel()
+	policy, err := NewPolicy(nil, []string{"regexp:docker\\.io/.*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for input, allowed := range map[string]bool{"nginx": false, "user/app": false, "example.com/app": true} {
+		named, err := ParseNormalizedNamed(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := policy.Validate(named); (err == nil) != allowed {
+			t.Errorf("%s: unexpected result: %v", input, err)
+		}
+	}
+}
+
+func TestNewPolicyInvalid(t *testing.T) {
+	t.Parallel()
+	for _, rule := range []string{"[", "regexp:("} {
+		if _, err := NewPolicy([]string{rule}, nil); err == nil {
+			t.Errorf("%q: expected error", rule)
+		}
+	}
+}
+
+func TestValidateK8sImage(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input string
+		valid bool
+	}{
+		{input: "nginx", valid: true},
+		{input: "nginx:1.25", valid: true},
+		{input: "registry.k8s.io/pause:3.9", valid: true},
+		{input: "gcr.io/project/app@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa", valid: true},
+		{input: "localhost:5000/app:1.0@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa", valid: true},
+		{input: ""},
+		{input: " nginx"},
+		{input: "nginx:1.25\n"},
+		{input: "Nginx"},
+		{input: "nginx:"},
+		{input: "nginx@sha256:abc"},
+		{input: "86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			if err := ValidateK8sImage(testcase.input); (err == nil) != testcase.valid {
+				t.Errorf("unexpected result: %v, expected valid: %v", err, testcase.valid)
+			}
+		})
+	}
+}
+
+func TestRejectRegistries(t *testing.T) {
+	t.Parallel()
+	reserved := []string{"index.docker.io", "Quay.io"}
+	validate := RejectRegistries(reserved)
+	reserved[1] = "registry.example.com"
+	testcases := []struct {
+		input    string
+		rejected bool
+	}{
+		{input: "nginx", rejected: true},
+		{input: "docker.io/user/app:1.0", rejected: true},
+		{input: "registry-1.docker.io/library/nginx", rejected: true},
+		{input: "quay.io/project/app", rejected: true},
+		{input: "registry.example.com/app", rejected: false},
+		{input: "registry.example.com:5000/app", rejected: false},
+		{input: "quay.io:443/project/app", rejected: false},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			err := validate(mustParseNormalized(t, testcase.input))
+			if testcase.rejected != errors.Is(err, ErrRegistryReserved) || (!testcase.rejected && err != nil) {
+				t.Errorf("unexpected result: %v, expected rejected: %v", err, testcase.rejected)
+			}
+		})
+	}
+}
+
+func TestCheckLimits(t *testing.T) {
+	t.Parallel()
+	// References are constructed directly, as the parser rejects references
+	// exceeding the limits.
+	tagged := func(repo repository, tag string) Named {
+		return taggedReference{namedRepository: repo, tag: tag}
+	}
+	testcases := []struct {
+		name string
+		ref  Named
+		err  error
+	}{
+		{
+			name: "domain at limit",
+			ref:  repository{domain: strings.Repeat("a", DomainMaxLength), path: "a"},
+		},
+		{
+			name: "domain over limit",
+			ref:  repository{domain: strings.Repeat("a", DomainMaxLength+1), path: "a"},
+			err:  ErrComponentTooLong,
+		},
+		{
+			name: "path component at limit",
+			ref:  repository{path: strings.Repeat("a", PathComponentMaxLength)},
+		},
+		{
+			name: "path component over limit",
+			ref:  repository{domain: "example.com", path: "team/" + strings.Repeat("a", PathComponentMaxLength+1)},
+			err:  ErrComponentTooLong,
+		},
+		{
+			name: "tag at limit",
+			ref:  tagged(repository{domain: "example.com", path: "app"}, strings.Repeat("a", TagMaxLength)),
+		},
+		{
+			name: "tag over limit",
+			ref:  tagged(repository{domain: "example.com", path: "app"}, strings.Repeat("a", TagMaxLength+1)),
+			err:  ErrComponentTooLong,
+		},
+		{
+			name: "name at limit",
+			ref:  repository{domain: "example.com", path: strings.Repeat("a/", (NameTotalMaxLength-len("example.com/"))/2) + "a"},
+		},
+		{
+			name: "name over limit",
+			ref:  repository{domain: "example.com", path: strings.Repeat("a/", (NameTotalMaxLength-len("example.com/"))/2) + "ab"},
+			err:  ErrNameTooLong,
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.name, func(t *testing.T) {
+			t.Parallel()
+			err := CheckLimits(testcase.ref)
+			if testcase.err == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, testcase.err) {
+				t.Errorf("unexpected error: got %v, expected %v", err, testcase.err)
+			}
+		})
+	}
+
+	// Within the limits, CheckLimits agrees with the parser.
+	for _, testcase := range testcases {
+		if testcase.err != nil {
+			continue
+		}
+		if _, err := Parse(testcase.ref.String()); err != nil {
+			t.Errorf("%s: %q is rejected by Parse: %v", testcase.name, testcase.ref.String(), err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package reference
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PortabilityWarnings returns a warning for each way in which s is
+// interpreted differently by Docker-style normalization
+// ([ParseNormalizedNamed]) and by tools that strictly follow the reference
+// grammar without normalization ([Parse]), such as many OCI tools. Fully
+// qualified, lowercase references are interpreted the same way by both, and
+// have no warnings. No warnings are returned if s is rejected by both.
+func PortabilityWarnings(s string) []string {
+	strict, strictErr := Parse(s)
+	normalized, err := parseNormalized(s)
+	switch {
+	case strictErr != nil && err != nil:
+		return nil
+	case err != nil:
+		if anchoredIdentifierRegexp.MatchString(s) {
+			return []string{fmt.Sprintf("%q is a repository name for OCI tools, but an image ID for Docker", s)}
+		}
+		return []string{fmt.Sprintf("%q is accepted by OCI tools, but rejected by Docker: %v", s, err)}
+	case strictErr != nil:
+		return []string{fmt.Sprintf("%q is accepted by Docker, but rejected by OCI tools: %v", s, strictErr)}
+	}
+
+	named, ok := strict.(Named)
+	if !ok {
+		return nil
+	}
+	var warnings []string
+	switch domain := Domain(named); {
+	case domain == "":
+		warnings = append(warnings, fmt.Sprintf("%q has no domain; Docker uses %q", s, defaultDomain))
+	case !isDomainComponent(domain):
+		warnings = append(warnings, fmt.Sprintf("%q is a domain for OCI tools, but a path component for Docker, which uses %q", domain, defaultDomain))
+	case domain == legacyDefaultDomain:
+		warnings = append(warnings, fmt.Sprintf("%q is replaced with %q by Docker", legacyDefaultDomain, defaultDomain))
+	case strings.ToLower(domain) != domain:
+		warnings = append(warnings, fmt.Sprintf("%q is only a domain because it contains uppercase characters, which other tools may reject", domain))
+	}
+	for _, event := range normalizationEvents(s, isDomainComponent) {
+		if event == EventLibraryPrefix {
+			warnings = append(warnings, fmt.Sprintf("Docker adds the %q prefix to %q", officialRepoPrefix, Path(normalized)[len(officialRepoPrefix):]))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,45 @@
+package reference
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPortabilityWarnings(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		warnings []string
+	}{
+		{input: "nginx", warnings: []string{"has no domain", "adds the \"library/\" prefix"}},
+		{input: "nginx:1.25", warnings: []string{"has no domain", "adds the \"library/\" prefix"}},
+		{input: "library/nginx", warnings: []string{"\"library\" is a domain for OCI tools"}},
+		{input: "user/app", warnings: []string{"\"user\" is a domain for OCI tools"}},
+		{input: "test_com/foo", warnings: []string{"has no domain"}},
+		{input: "Foo/bar", warnings: []string{"uppercase"}},
+		{input: "index.docker.io/nginx", warnings: []string{"\"index.docker.io\" is replaced", "adds the \"library/\" prefix"}},
+		{input: "docker.io/nginx", warnings: []string{"adds the \"library/\" prefix"}},
+		{input: "b4b4ccf4d4d7d0a2a8a3c8a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5", warnings: []string{"image ID"}},
+		{input: "docker.io/library/nginx:1.25"},
+		{input: "docker.io/user/app"},
+		{input: "example.com/app:1.0"},
+		{input: "localhost:5000/app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{input: "localhost/app"},
+		{input: "example.com/App"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			warnings := PortabilityWarnings(testcase.input)
+			if len(warnings) != len(testcase.warnings) {
+				t.Fatalf("unexpected warnings: got %q, expected %d", warnings, len(testcase.warnings))
+			}
+			for i, expected := range testcase.warnings {
+				if !strings.Contains(warnings[i], expected) {
+					t.Errorf("unexpected warning: got %q, expected it to contain %q", warnings[i], expected)
+				}
+			}
+		})
+	}
+}
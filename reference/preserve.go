@@ -0,0 +1,85 @@
+package reference
+
+// Preserved is a normalized reference that retains the input it was parsed
+// from, as returned by [ParseNormalizedNamedPreserve].
+type Preserved interface {
+	Named
+
+	// Original returns the input the reference was parsed from, exactly as
+	// it was written, for example, to show it in error messages.
+	Original() string
+}
+
+// ParseNormalizedNamedPreserve parses s in the same way as
+// [ParseNormalizedNamed], and returns a reference that retains s. String
+// returns the normalized form of the reference, and Original returns s. The
+// returned reference implements the same interfaces (such as [Tagged] and
+// [Digested]) as the one returned by ParseNormalizedNamed.
+func ParseNormalizedNamedPreserve(s string) (Preserved, error) {
+	named, err := parseNormalized(s)
+	if err != nil {
+		return nil, err
+	}
+	switch r := named.(type) {
+	case namedTaggedDigested:
+		return preservedTaggedDigested{namedTaggedDigested: r, original: s}, nil
+	case Canonical:
+		return preservedCanonical{Canonical: r, original: s}, nil
+	case NamedTagged:
+		return preservedTagged{NamedTagged: r, original: s}, nil
+	default:
+		return preservedNamed{Named: r, original: s}, nil
+	}
+}
+
+type preservedNamed struct {
+	Named
+	original string
+}
+
+func (p preservedNamed) Original() string {
+	return p.original
+}
+
+func (p preservedNamed) Familiar() Named {
+	return p.Named.(normalizedNamed).Familiar()
+}
+
+type preservedTagged struct {
+	NamedTagged
+	original string
+}
+
+func (p preservedTagged) Original() string {
+	return p.original
+}
+
+func (p preservedTagged) Familiar() Named {
+	return p.NamedTagged.(normalizedNamed).Familiar()
+}
+
+type preservedCanonical struct {
+	Canonical
+	original string
+}
+
+func (p preservedCanonical) Original() string {
+	return p.original
+}
+
+func (p preservedCanonical) Familiar() Named {
+	return p.Canonical.(normalizedNamed).Familiar()
+}
+
+type preservedTaggedDigested struct {
+	namedTaggedDigested
+	original string
+}
+
+func (p preservedTaggedDigested) Original() string {
+	return p.original
+}
+
+func (p preservedTaggedDigested) Familiar() Named {
+	return p.namedTaggedDigested.(normalizedNamed).Familiar()
+}
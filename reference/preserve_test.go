@@ -0,0 +1,80 @@
+package reference
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestParseNormalizedNamedPreserve(t *testing.T) {
+	t.Parallel()
+	for i, tc := range repositoryInfoTestcases {
+		tc := tc
+		for _, r := range tc.refStrings() {
+			r := r
+			t.Run(strconv.Itoa(i)+"/"+r, func(t *testing.T) {
+				t.Parallel()
+				preserved, err := ParseNormalizedNamedPreserve(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if preserved.Original() != r {
+					t.Errorf("unexpected original: got %q, expected %q", preserved.Original(), r)
+				}
+				if preserved.String() != tc.FullName {
+					t.Errorf("unexpected string: got %q, expected %q", preserved.String(), tc.FullName)
+				}
+				if actual := FamiliarName(preserved); actual != tc.FamiliarName {
+					t.Errorf("unexpected familiar name: got %q, expected %q", actual, tc.FamiliarName)
+				}
+				if actual := Domain(preserved); actual != tc.Domain {
+					t.Errorf("unexpected domain: got %q, expected %q", actual, tc.Domain)
+				}
+			})
+		}
+	}
+}
+
+func TestParseNormalizedNamedPreserveTypes(t *testing.T) {
+	t.Parallel()
+	const dgst = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	testcases := []struct {
+		input    string
+		expected string
+		tagged   bool
+		digested bool
+	}{
+		{input: "nginx", expected: "docker.io/library/nginx"},
+		{input: "nginx:1.25", expected: "docker.io/library/nginx:1.25", tagged: true},
+		{input: "nginx@" + dgst, expected: "docker.io/library/nginx@" + dgst, digested: true},
+		{input: "nginx:1.25@" + dgst, expected: "docker.io/library/nginx:1.25@" + dgst, tagged: true, digested: true},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			preserved, err := ParseNormalizedNamedPreserve(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if preserved.Original() != testcase.input {
+				t.Errorf("unexpected original: got %q, expected %q", preserved.Original(), testcase.input)
+			}
+			if preserved.String() != testcase.expected {
+				t.Errorf("unexpected string: got %q, expected %q", preserved.String(), testcase.expected)
+			}
+			if _, ok := preserved.(Tagged); ok != testcase.tagged {
+				t.Errorf("unexpected Tagged: got %v, expected %v", ok, testcase.tagged)
+			}
+			if _, ok := preserved.(Digested); ok != testcase.digested {
+				t.Errorf("unexpected Digested: got %v, expected %v", ok, testcase.digested)
+			}
+			if actual := FamiliarString(preserved); actual != FamiliarString(mustParseNormalized(t, testcase.input)) {
+				t.Errorf("unexpected familiar string: %q", actual)
+			}
+		})
+	}
+
+	if _, err := ParseNormalizedNamedPreserve("Nginx"); err == nil {
+		t.Error("expected error for invalid reference")
+	}
+}
@@ -0,0 +1,54 @@
+package reference
+
+// RateLimitGranularity describes how a registry applies rate limits.
+type RateLimitGranularity int
+
+const (
+	// RateLimitPerRegistry is used for registries that apply a single rate
+	// limit to all repositories.
+	RateLimitPerRegistry RateLimitGranularity = iota
+
+	// RateLimitPerRepository is used for registries that apply a rate limit
+	// to each repository.
+	RateLimitPerRepository
+)
+
+// RateLimiter computes the keys of rate limit buckets, using the rate limit
+// granularity of each registry. The zero value uses the granularities of
+// Docker Hub, which limits per repository, and [RateLimitPerRegistry] for all
+// other registries.
+type RateLimiter struct {
+	// Granularities holds the rate limit granularity of registries, keyed by
+	// canonical domain (see [CanonicalDomain]). Registries without an entry
+	// use [RateLimitPerRegistry]. If nil, Docker Hub uses
+	// [RateLimitPerRepository].
+	Granularities map[string]RateLimitGranularity
+}
+
+func (l RateLimiter) granularity(domain string) RateLimitGranularity {
+	if l.Granularities == nil {
+		if domain == defaultDomain {
+			return RateLimitPerRepository
+		}
+		return RateLimitPerRegistry
+	}
+	return l.Granularities[domain]
+}
+
+// Key returns the key of the rate limit bucket that applies to ref: the
+// [RepositoryKey] for registries that limit per repository, and the
+// canonical domain for all other registries.
+func (l RateLimiter) Key(ref Named) string {
+	domain, _ := canonicalRepository(Domain(ref), Path(ref))
+	if l.granularity(domain) == RateLimitPerRepository {
+		return RepositoryKey(ref)
+	}
+	return domain
+}
+
+// RateLimitKey returns the key of the rate limit bucket that applies to
+// ref, using the zero value of [RateLimiter]: the [RepositoryKey] for Docker
+// Hub, and the canonical domain for all other registries.
+func RateLimitKey(ref Named) string {
+	return RateLimiter{}.Key(ref)
+}
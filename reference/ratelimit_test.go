@@ -0,0 +1,60 @@
+package reference
+
+import "testing"
+
+func TestRateLimitKey(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "nginx:1.25", expected: "docker.io/library/nginx"},
+		{input: "index.docker.io/library/nginx@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", expected: "docker.io/library/nginx"},
+		{input: "user/app", expected: "docker.io/user/app"},
+		{input: "registry.example.com/team/app:1.0", expected: "registry.example.com"},
+		{input: "registry.example.com/team/other", expected: "registry.example.com"},
+		{input: "localhost:5000/app", expected: "localhost:5000"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			named, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key := RateLimitKey(named); key != testcase.expected {
+				t.Errorf("unexpected key: got %q, expected %q", key, testcase.expected)
+			}
+		})
+	}
+}
+
+func TestRateLimiterKey(t *testing.T) {
+	t.Parallel()
+	limiter := RateLimiter{Granularities: map[string]RateLimitGranularity{
+		"registry.example.com": RateLimitPerRepository,
+	}}
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "registry.example.com/team/app:1.0", expected: "registry.example.com/team/app"},
+		{input: "registry.example.com/team/other", expected: "registry.example.com/team/other"},
+		{input: "nginx:1.25", expected: "docker.io"},
+		{input: "localhost:5000/app", expected: "localhost:5000"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			named, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key := limiter.Key(named); key != testcase.expected {
+				t.Errorf("unexpected key: got %q, expected %q", key, testcase.expected)
+			}
+		})
+	}
+}
@@ -37,8 +37,34 @@ import (
 const (
 	// NameTotalLengthMax is the maximum total number of characters in a repository name.
 	NameTotalLengthMax = 255
+
+	// NameTotalMaxLength is the maximum total number of characters in a
+	// repository name, including the domain, as checked by [CheckLimits].
+	// It is the same as NameTotalLengthMax.
+	NameTotalMaxLength = NameTotalLengthMax
+
+	// DomainMaxLength is the maximum number of characters in the domain of
+	// a repository name, including the port, as checked by [CheckLimits].
+	// The domain is only limited by the length of the name, which must also
+	// hold a "/" and a path of at least one character.
+	DomainMaxLength = NameTotalMaxLength - 2
+
+	// PathComponentMaxLength is the maximum number of characters in each
+	// component of the path of a repository name, as checked by
+	// [CheckLimits]. Components are only limited by the length of the name.
+	PathComponentMaxLength = NameTotalMaxLength
+
+	// TagMaxLength is the maximum number of characters in a tag, as defined
+	// by the reference grammar.
+	TagMaxLength = 128
 )
 
+// TotalLengthMax is the default maximum total number of characters in a
+// reference, including the domain, path, tag, and digest, accepted by
+// [Parse]. Use [ParseWithMaxLength] to match registries that use a lower
+// limit.
+const TotalLengthMax = 4096
+
 var (
 	// ErrReferenceInvalidFormat represents an error while trying to parse a string as a reference.
 	ErrReferenceInvalidFormat = errors.New("invalid reference format")
@@ -60,8 +86,94 @@ var (
 
 	// ErrNameNotCanonical is returned when a name is not canonical.
 	ErrNameNotCanonical = errors.New("repository name must be canonical")
+
+	// ErrPercentEncoded is returned for references that contain percent-encoded
+	// characters, such as "library%2Fnginx". It wraps ErrReferenceInvalidFormat.
+	// Use ParseURLDecoded to accept such references.
+	ErrPercentEncoded = fmt.Errorf("%w: reference is percent-encoded; decode it before parsing", ErrReferenceInvalidFormat)
+
+	// ErrControlCharacter is returned for references that contain control
+	// characters (such as "\r" or NUL) or a byte order mark. It wraps
+	// ErrReferenceInvalidFormat. Use ParseLenient to accept references with
+	// leading or trailing control characters.
+	ErrControlCharacter = fmt.Errorf("%w: reference contains control characters", ErrReferenceInvalidFormat)
+
+	// ErrAngleBrackets is returned for references that start or end with an
+	// angle bracket, such as "<docker.io/library/nginx>", as produced by
+	// auto-linking in some issue trackers and Markdown renderers. It wraps
+	// ErrReferenceInvalidFormat. Use ParseLenient to accept references enclosed
+	// in a pair of angle brackets.
+	ErrAngleBrackets = fmt.Errorf("%w: reference is enclosed in angle brackets", ErrReferenceInvalidFormat)
 )
 
+// ReferenceTooLongError is returned when a reference is longer than
+// [TotalLengthMax], or than the limit passed to [ParseWithMaxLength]. It can be matched using
+// errors.Is(err, ReferenceTooLongError{}), and also matches
+// [ErrReferenceInvalidFormat].
+type ReferenceTooLongError struct{}
+
+func (ReferenceTooLongError) Error() string {
+	return "invalid reference format: reference exceeds the maximum total length"
+}
+
+// Is reports whether target is [ErrReferenceInvalidFormat].
+func (ReferenceTooLongError) Is(target error) bool {
+	return target == ErrReferenceInvalidFormat
+}
+
+// EmptyTagError is returned when a reference has a tag separator (":") that
+// is not followed by a tag, for example "nginx:". It can be matched using
+// errors.Is(err, EmptyTagError{}), and also matches [ErrReferenceInvalidFormat].
+type EmptyTagError struct{}
+
+func (EmptyTagError) Error() string {
+	return "invalid reference format: tag is empty"
+}
+
+// Is reports whether target is [ErrReferenceInvalidFormat].
+func (EmptyTagError) Is(target error) bool {
+	return target == ErrReferenceInvalidFormat
+}
+
+// EmptyDigestError is returned when a reference has a digest separator ("@")
+// that is not followed by a digest, for example "nginx@". It can be matched
+// using errors.Is(err, EmptyDigestError{}), and also matches
+// [ErrReferenceInvalidFormat].
+type EmptyDigestError struct{}
+
+func (EmptyDigestError) Error() string {
+	return "invalid reference format: digest is empty"
+}
+
+// Is reports whether target is [ErrReferenceInvalidFormat].
+func (EmptyDigestError) Is(target error) bool {
+	return target == ErrReferenceInvalidFormat
+}
+
+// UppercaseDigestError is returned when the encoded part of a digest contains
+// uppercase hexadecimal characters, as canonical digests are lowercase. It can
+// be matched using errors.Is(err, UppercaseDigestError{}), and also matches
+// [ErrDigestInvalidFormat] and [digest.ErrDigestInvalidFormat]. Use
+// [ParseLowercaseDigest] to accept such digests.
+type UppercaseDigestError struct{}
+
+func (UppercaseDigestError) Error() string {
+	return "invalid digest format: digest must use lowercase hexadecimal characters"
+}
+
+// Is reports whether target is [ErrDigestInvalidFormat] or
+// [digest.ErrDigestInvalidFormat].
+func (UppercaseDigestError) Is(target error) bool {
+	return target == ErrDigestInvalidFormat || target == digest.ErrDigestInvalidFormat
+}
+
+// hasUppercaseHex reports whether the encoded part of dgst contains uppercase
+// hexadecimal characters.
+func hasUppercaseHex(dgst string) bool {
+	_, encoded, _ := strings.Cut(dgst, ":")
+	return strings.ContainsAny(encoded, "ABCDEF")
+}
+
 // Reference is an opaque object reference identifier that may include
 // modifiers such as a hostname, name, tag, and digest.
 type Reference interface {
@@ -165,6 +277,23 @@ func Path(named Named) (name string) {
 	return path
 }
 
+// AppendPath appends the path of the [Named] reference, as returned by [Path],
+// to dst and returns the extended buffer. It does not allocate if dst has
+// sufficient capacity, including for [Named] references that were not
+// produced by this package.
+func AppendPath(dst []byte, ref Named) []byte {
+	if r, ok := ref.(namedRepository); ok {
+		return append(dst, r.Path()...)
+	}
+	// Split the name by index instead of using splitDomain, as
+	// FindStringSubmatch allocates.
+	name := ref.Name()
+	if i := strings.IndexByte(name, '/'); i != -1 && anchoredDomainRegexp.MatchString(name[:i]) && anchoredRemoteNameRegexp.MatchString(name[i+1:]) {
+		return append(dst, name[i+1:]...)
+	}
+	return append(dst, name...)
+}
+
 func splitDomain(name string) (string, string) {
 	match := anchoredNameRegexp.FindStringSubmatch(name)
 	if len(match) != 3 {
@@ -188,15 +317,46 @@ func SplitHostname(named Named) (string, string) {
 
 // Parse parses s and returns a syntactically valid Reference.
 // If an error was encountered it is returned, along with a nil Reference.
+// References longer than [TotalLengthMax] are rejected with [ReferenceTooLongError].
 func Parse(s string) (Reference, error) {
+	return ParseWithMaxLength(s, TotalLengthMax)
+}
+
+// ParseWithMaxLength parses s in the same way as [Parse], but rejects
+// references longer than maxLength characters, instead of [TotalLengthMax],
+// with [ReferenceTooLongError].
+func ParseWithMaxLength(s string, maxLength int) (Reference, error) {
+	if len(s) > maxLength {
+		return nil, fmt.Errorf("%w: %d characters, must not be more than %d", ReferenceTooLongError{}, len(s), maxLength)
+	}
 	matches := ReferenceRegexp.FindStringSubmatch(s)
 	if matches == nil {
 		if s == "" {
 			return nil, ErrNameEmpty
 		}
+		if isPercentEncoded(s) {
+			return nil, ErrPercentEncoded
+		}
+		if hasControlCharacter(s) {
+			return nil, ErrControlCharacter
+		}
+		if strings.HasPrefix(s, "<") || strings.HasSuffix(s, ">") {
+			return nil, ErrAngleBrackets
+		}
 		if ReferenceRegexp.FindStringSubmatch(strings.ToLower(s)) != nil {
 			return nil, ErrNameContainsUppercase
 		}
+		if trimmed := s[:len(s)-1]; ReferenceRegexp.MatchString(trimmed) {
+			switch s[len(s)-1] {
+			case ':':
+				return nil, EmptyTagError{}
+			case '@':
+				return nil, EmptyDigestError{}
+			}
+		}
+		if name, dgst, ok := strings.Cut(s, ":@"); ok && ReferenceRegexp.MatchString(name+"@"+dgst) {
+			return nil, EmptyTagError{}
+		}
 		return nil, ErrReferenceInvalidFormat
 	}
 
@@ -220,6 +380,9 @@ func Parse(s string) (Reference, error) {
 		tag:             matches[2],
 	}
 	if matches[3] != "" {
+		if hasUppercaseHex(matches[3]) {
+			return nil, UppercaseDigestError{}
+		}
 		var err error
 		ref.digest, err = digest.Parse(matches[3])
 		if err != nil {
@@ -235,12 +398,43 @@ func Parse(s string) (Reference, error) {
 	return r, nil
 }
 
+// isPercentEncoded reports whether s contains a percent-encoded character
+// ("%" followed by two hexadecimal digits), which is never valid in a
+// reference.
+func isPercentEncoded(s string) bool {
+	for i := 0; i+2 < len(s); i++ {
+		if s[i] == '%' && isHex(s[i+1]) && isHex(s[i+2]) {
+			return true
+		}
+	}
+	return false
+}
+
+// byteOrderMark is the Unicode byte order mark, which may be found at the
+// start of text files.
+const byteOrderMark = "\ufeff"
+
+// hasControlCharacter reports whether s contains an ASCII control character
+// or a byte order mark.
+func hasControlCharacter(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] == 0x7f {
+			return true
+		}
+	}
+	return strings.Contains(s, byteOrderMark)
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
 // ParseNamed parses s and returns a syntactically valid reference implementing
 // the Named interface. The reference must have a name and be in the canonical
 // form, otherwise an error is returned.
 // If an error was encountered it is returned, along with a nil Reference.
 func ParseNamed(s string) (Named, error) {
-	named, err := ParseNormalizedNamed(s)
+	named, err := parseNormalized(s)
 	if err != nil {
 		return nil, err
 	}
@@ -267,6 +461,40 @@ func WithName(name string) (Named, error) {
 	}, nil
 }
 
+// Join returns a named reference for the repository with the given domain
+// and path (remote-name). The domain may be empty. Unlike [WithName], the
+// input is already split, so it is not required to find the domain; domain and
+// path are validated separately.
+func Join(domain, path string) (Named, error) {
+	if domain != "" && !anchoredDomainRegexp.MatchString(domain) {
+		return nil, ErrReferenceInvalidFormat
+	}
+	if !anchoredRemoteNameRegexp.MatchString(path) {
+		if path == "" {
+			return nil, ErrNameEmpty
+		}
+		return nil, ErrReferenceInvalidFormat
+	}
+	nameLen := len(path)
+	if domain != "" {
+		nameLen += len(domain) + len("/")
+	}
+	if nameLen > NameTotalLengthMax {
+		return nil, ErrNameTooLong
+	}
+	return repository{domain: domain, path: path}, nil
+}
+
+// JoinUnchecked is like [Join], but does not validate domain and path.
+//
+// This function is unsafe: it is intended for hot paths where domain and path
+// are already known to be valid, for example, because they were obtained from
+// [Domain] and [Path]. Passing invalid input results in a reference that
+// violates the reference grammar.
+func JoinUnchecked(domain, path string) Named {
+	return repository{domain: domain, path: path}
+}
+
 // WithTag combines the name from "name" and the tag from "tag" to form a
 // reference incorporating both the name and the tag.
 func WithTag(name Named, tag string) (NamedTagged, error) {
@@ -299,6 +527,9 @@ func WithDigest(name Named, digest digest.Digest) (Canonical, error) {
 	if !anchoredDigestRegexp.MatchString(digest.String()) {
 		return nil, ErrDigestInvalidFormat
 	}
+	if hasUppercaseHex(digest.String()) {
+		return nil, UppercaseDigestError{}
+	}
 	var repo repository
 	if r, ok := name.(namedRepository); ok {
 		repo.domain = r.Domain()
@@ -330,6 +561,19 @@ func TrimNamed(ref Named) Named {
 	return repo
 }
 
+// withRepository returns a reference with the name of repo, and the tag and
+// digest (if any) of ref.
+func withRepository(ref Reference, repo repository) Named {
+	r := reference{namedRepository: repo}
+	if tagged, ok := ref.(Tagged); ok {
+		r.tag = tagged.Tag()
+	}
+	if digested, ok := ref.(Digested); ok {
+		r.digest = digested.Digest()
+	}
+	return getBestReferenceType(r).(Named)
+}
+
 func getBestReferenceType(ref reference) Reference {
 	if ref.Name() == "" {
 		// Allow digest only references
@@ -4,6 +4,8 @@ import (
 	_ "crypto/sha256"
 	_ "crypto/sha512"
 	"encoding/json"
+	"errors"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -266,6 +268,42 @@ func TestReferenceParse(t *testing.T) {
 			input: "[fe80::1%@invalidzone]:5000/repo",
 			err:   ErrReferenceInvalidFormat,
 		},
+		{
+			input: "example.com/foo@sha256:86E0E091D0DA6BDE2456DBB48306F3956BBEB2EAE1B5B9A43045843F69FE4AAA",
+			err:   UppercaseDigestError{},
+		},
+		{
+			input: "example.com/foo:tag@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaA",
+			err:   UppercaseDigestError{},
+		},
+		{
+			input: "library%2Fnginx",
+			err:   ErrPercentEncoded,
+		},
+		{
+			input: "example.com/app%3A1.0",
+			err:   ErrPercentEncoded,
+		},
+		{
+			input: "example.com/app:1.0\r\n",
+			err:   ErrControlCharacter,
+		},
+		{
+			input: "\ufeffexample.com/app",
+			err:   ErrControlCharacter,
+		},
+		{
+			input: "example.com/app\x00:1.0",
+			err:   ErrControlCharacter,
+		},
+		{
+			input: "<docker.io/library/nginx>",
+			err:   ErrAngleBrackets,
+		},
+		{
+			input: "<docker.io/library/nginx",
+			err:   ErrAngleBrackets,
+		},
 	}
 	for _, testcase := range referenceTestcases {
 		testcase := testcase
@@ -328,6 +366,55 @@ func TestReferenceParse(t *testing.T) {
 	}
 }
 
+func TestParseTotalLengthMax(t *testing.T) {
+	t.Parallel()
+	longest := strings.Repeat("a/", 127) + "a:" + strings.Repeat("t", 128) + "@sha512:" + strings.Repeat("f", 128)
+	if _, err := Parse(longest); err != nil {
+		t.Errorf("unexpected error for the longest valid reference: %v", err)
+	}
+	_, err := Parse(strings.Repeat("a", TotalLengthMax+1))
+	if !errors.Is(err, ReferenceTooLongError{}) {
+		t.Errorf("unexpected error over the limit: got %v, expected %v", err, ReferenceTooLongError{})
+	}
+	if !errors.Is(err, ErrReferenceInvalidFormat) {
+		t.Errorf("expected %v to match %v", err, ErrReferenceInvalidFormat)
+	}
+}
+
+func TestParseWithMaxLength(t *testing.T) {
+	t.Parallel()
+	const ref = "example.com/app:1.23"
+	testcases := []struct {
+		maxLength int
+		tooLong   bool
+	}{
+		{maxLength: TotalLengthMax},
+		{maxLength: len(ref) + 1},
+		{maxLength: len(ref)},
+		{maxLength: len(ref) - 1, tooLong: true},
+		{maxLength: len("example.com"), tooLong: true},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(strconv.Itoa(testcase.maxLength), func(t *testing.T) {
+			t.Parallel()
+			parsed, err := ParseWithMaxLength(ref, testcase.maxLength)
+			if testcase.tooLong {
+				if !errors.Is(err, ReferenceTooLongError{}) || !errors.Is(err, ErrReferenceInvalidFormat) {
+					t.Errorf("unexpected error: got %v, expected %v", err, ReferenceTooLongError{})
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.String() != ref {
+				t.Errorf("unexpected: got %q, expected %q", parsed.String(), ref)
+			}
+		})
+	}
+}
+
 // TestWithNameFailure tests cases where WithName should fail. Cases where it
 // should succeed are covered by TestSplitHostname, below.
 func TestWithNameFailure(t *testing.T) {
@@ -749,3 +836,238 @@ func TestParseNamed(t *testing.T) {
 		})
 	}
 }
+
+func TestJoin(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		domain string
+		path   string
+		name   string
+		err    error
+	}{
+		{
+			domain: "test.com",
+			path:   "foo",
+			name:   "test.com/foo",
+		},
+		{
+			domain: "test.com:8080",
+			path:   "foo/bar",
+			name:   "test.com:8080/foo/bar",
+		},
+		{
+			domain: "[fc00::1]:5000",
+			path:   "foo",
+			name:   "[fc00::1]:5000/foo",
+		},
+		{
+			path: "foo",
+			name: "foo",
+		},
+		{
+			domain: "test.com",
+			err:    ErrNameEmpty,
+		},
+		{
+			domain: "-test.com",
+			path:   "foo",
+			err:    ErrReferenceInvalidFormat,
+		},
+		{
+			domain: "test.com/foo",
+			path:   "bar",
+			err:    ErrReferenceInvalidFormat,
+		},
+		{
+			domain: "test.com",
+			path:   "Foo",
+			err:    ErrReferenceInvalidFormat,
+		},
+		{
+			domain: "test.com",
+			path:   "foo//bar",
+			err:    ErrReferenceInvalidFormat,
+		},
+		{
+			domain: "test.com",
+			path:   strings.Repeat("a", NameTotalLengthMax),
+			err:    ErrNameTooLong,
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.domain+"/"+testcase.path, func(t *testing.T) {
+			t.Parallel()
+			named, err := Join(testcase.domain, testcase.path)
+			if err != testcase.err {
+				t.Fatalf("unexpected error: got %v, expected %v", err, testcase.err)
+			}
+			if err != nil {
+				return
+			}
+			if named.Name() != testcase.name {
+				t.Errorf("unexpected name: got %q, expected %q", named.Name(), testcase.name)
+			}
+			if Domain(named) != testcase.domain || Path(named) != testcase.path {
+				t.Errorf("unexpected components: got (%q, %q), expected (%q, %q)", Domain(named), Path(named), testcase.domain, testcase.path)
+			}
+			if unchecked := JoinUnchecked(testcase.domain, testcase.path); unchecked != named {
+				t.Errorf("unexpected unchecked result: got %#v, expected %#v", unchecked, named)
+			}
+		})
+	}
+}
+
+func BenchmarkJoin(b *testing.B) {
+	const domain, path = "registry.example.com:5000", "team/project/app"
+	b.Run("Join", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Join(domain, path); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("JoinUnchecked", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = JoinUnchecked(domain, path)
+		}
+	})
+	b.Run("Parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Parse(domain + "/" + path); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestParseEmptyTagOrDigest(t *testing.T) {
+	t.Parallel()
+	const dgst = "sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+	testcases := []struct {
+		input string
+		err   error
+	}{
+		{input: "nginx:", err: EmptyTagError{}},
+		{input: "example.com:5000/nginx:", err: EmptyTagError{}},
+		{input: "nginx:@" + dgst, err: EmptyTagError{}},
+		{input: "nginx@", err: EmptyDigestError{}},
+		{input: "nginx:1.25@", err: EmptyDigestError{}},
+		{input: "nginx:1.25"},
+		{input: "nginx@" + dgst},
+		{input: "nginx:1.25@" + dgst},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			for _, parse := range []func(string) (Reference, error){
+				Parse,
+				func(s string) (Reference, error) { return ParseNormalizedNamed(s) },
+			} {
+				_, err := parse(testcase.input)
+				if testcase.err == nil {
+					if err != nil {
+						t.Errorf("unexpected error: %v", err)
+					}
+					continue
+				}
+				if !errors.Is(err, testcase.err) {
+					t.Errorf("expected %v, got %v", testcase.err, err)
+				}
+				if !errors.Is(err, ErrReferenceInvalidFormat) {
+					t.Errorf("expected error to match %v", ErrReferenceInvalidFormat)
+				}
+			}
+		})
+	}
+}
+
+// foreignNamed is a Named reference that was not produced by this package.
+type foreignNamed string
+
+func (n foreignNamed) Name() string {
+	return string(n)
+}
+
+func (n foreignNamed) String() string {
+	return string(n)
+}
+
+func TestAppendPath(t *testing.T) {
+	// Not parallel, as testing.AllocsPerRun is not allowed in parallel tests.
+	var refs []Named
+	for _, input := range []string{
+		"nginx",
+		"docker.io/library/nginx:1.25",
+		"example.com:5000/team/app@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+		"[fc00::1]:5000/a/b/c",
+	} {
+		ref, err := ParseNormalizedNamed(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs = append(refs, ref)
+	}
+	for _, name := range []string{
+		"nginx",
+		"docker.io/library/nginx",
+		"example.com:5000/team/app",
+		"[fc00::1]:5000/a/b/c",
+		"localhost/app",
+		"not_a_domain/app",
+		"example.com/Invalid",
+		"example.com/",
+	} {
+		refs = append(refs, foreignNamed(name))
+	}
+
+	for _, ref := range refs {
+		buf := []byte("prefix:")
+		if actual, expected := string(AppendPath(buf, ref)), "prefix:"+Path(ref); actual != expected {
+			t.Errorf("%T %q: unexpected: got %q, expected %q", ref, ref.String(), actual, expected)
+		}
+
+		buf = make([]byte, 0, 64)
+		if allocs := testing.AllocsPerRun(100, func() { buf = AppendPath(buf[:0], ref) }); allocs != 0 {
+			t.Errorf("%T %q: expected no allocations, got %v", ref, ref.String(), allocs)
+		}
+	}
+}
+
+// TestParseErrorsMatchInvalidFormat verifies that the specific errors for
+// invalid references still match ErrReferenceInvalidFormat, which callers
+// may check for.
+func TestParseErrorsMatchInvalidFormat(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input string
+		err   error
+	}{
+		{input: "library%2Fnginx", err: ErrPercentEncoded},
+		{input: "example.com/app%3A1.0", err: ErrPercentEncoded},
+		{input: "example.com/app:1.0\r", err: ErrControlCharacter},
+		{input: "\ufeffexample.com/app", err: ErrControlCharacter},
+		{input: "example.com/app\x00:1.0", err: ErrControlCharacter},
+		{input: "<docker.io/library/nginx>", err: ErrAngleBrackets},
+		{input: "<nginx>", err: ErrAngleBrackets},
+		{input: "nginx>", err: ErrAngleBrackets},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			_, err := Parse(testcase.input)
+			if !errors.Is(err, testcase.err) || !errors.Is(err, ErrReferenceInvalidFormat) {
+				t.Errorf("Parse: unexpected error: got %v, expected %v matching %v", err, testcase.err, ErrReferenceInvalidFormat)
+			}
+			_, err = ParseNormalizedNamed(testcase.input)
+			if !errors.Is(err, testcase.err) || !errors.Is(err, ErrReferenceInvalidFormat) {
+				t.Errorf("ParseNormalizedNamed: unexpected error: got %v, expected %v matching %v", err, testcase.err, ErrReferenceInvalidFormat)
+			}
+		})
+	}
+}
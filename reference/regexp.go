@@ -129,6 +129,14 @@ var (
 	remoteName = pathComponent + anyTimes(`/`+pathComponent)
 	namePat    = optional(domainAndPort+`/`) + remoteName
 
+	// anchoredDomainRegexp matches a domain, including an optional port,
+	// anchored at the start and end of the matched string.
+	anchoredDomainRegexp = regexp.MustCompile(anchored(domainAndPort))
+
+	// anchoredRemoteNameRegexp matches the remote-name of a repository,
+	// anchored at the start and end of the matched string.
+	anchoredRemoteNameRegexp = regexp.MustCompile(anchored(remoteName))
+
 	// anchoredNameRegexp is used to parse a name value, capturing the
 	// domain and trailing components.
 	anchoredNameRegexp = regexp.MustCompile(anchored(optional(capture(domainAndPort), `/`), capture(remoteName)))
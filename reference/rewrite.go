@@ -0,0 +1,74 @@
+package reference
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TagRewriter rewrites the tag of references matching a rule, leaving the
+// name and digest untouched. References without a tag, or with a tag that
+// does not match the rule, are passed through unchanged.
+type TagRewriter struct {
+	match       *regexp.Regexp
+	replacement string
+}
+
+// NewTagRewriter returns a TagRewriter that rewrites tags matching pattern,
+// which is anchored at the start and end of the tag. The replacement may
+// reference capture groups of the pattern as described in [regexp.Regexp.Expand],
+// for example:
+//
+//	NewTagRewriter(`(.*)-rc`, "${1}")
+func NewTagRewriter(pattern, replacement string) (*TagRewriter, error) {
+	match, err := regexp.Compile(anchored(pattern))
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag rewrite pattern %q: %w", pattern, err)
+	}
+	return &TagRewriter{match: match, replacement: replacement}, nil
+}
+
+// NewTagPrefixRewriter returns a TagRewriter that replaces the prefix old of
+// a tag with new. An empty old prefix matches every tag, and can be used to
+// add a prefix; an empty new prefix strips old.
+func NewTagPrefixRewriter(old, new string) *TagRewriter {
+	return &TagRewriter{
+		match:       regexp.MustCompile(anchored(regexp.QuoteMeta(old), capture(`.*`))),
+		replacement: escapeExpand(new) + "${1}",
+	}
+}
+
+// NewTagSuffixRewriter returns a TagRewriter that replaces the suffix old of
+// a tag with new. An empty old suffix matches every tag, and can be used to
+// add a suffix; an empty new suffix strips old.
+func NewTagSuffixRewriter(old, new string) *TagRewriter {
+	return &TagRewriter{
+		match:       regexp.MustCompile(anchored(capture(`.*?`), regexp.QuoteMeta(old))),
+		replacement: "${1}" + escapeExpand(new),
+	}
+}
+
+// escapeExpand escapes s for literal use in a [regexp.Regexp.Expand] template.
+func escapeExpand(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
+
+// Apply returns ref with its tag rewritten. An error is returned if the
+// rewritten tag is not a valid tag.
+func (r *TagRewriter) Apply(ref Named) (Named, error) {
+	tagged, ok := ref.(Tagged)
+	if !ok {
+		return ref, nil
+	}
+	tag := tagged.Tag()
+	submatches := r.match.FindStringSubmatchIndex(tag)
+	if submatches == nil {
+		return ref, nil
+	}
+	newTag := string(r.match.ExpandString(nil, r.replacement, tag, submatches))
+	rewritten, err := WithTag(ref, newTag)
+	if err != nil {
+		return nil, fmt.Errorf("rewriting tag %q to %q: %w", tag, newTag, err)
+	}
+	return rewritten, nil
+}
@@ -0,0 +1,107 @@
+package reference
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTagRewriter(t *testing.T) {
+	t.Parallel()
+	stripRC, err := NewTagRewriter(`(.*)-rc`, "${1}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testcases := []struct {
+		name     string
+		rewriter *TagRewriter
+		input    string
+		expected string
+	}{
+		{
+			name:     "prefix strip",
+			rewriter: NewTagPrefixRewriter("v", ""),
+			input:    "docker.io/library/foo:v1.2.3",
+			expected: "docker.io/library/foo:1.2.3",
+		},
+		{
+			name:     "prefix replace",
+			rewriter: NewTagPrefixRewriter("staging-", "prod-"),
+			input:    "example.com/app:staging-42",
+			expected: "example.com/app:prod-42",
+		},
+		{
+			name:     "suffix add",
+			rewriter: NewTagSuffixRewriter("", "-debug"),
+			input:    "example.com/app:1.0",
+			expected: "example.com/app:1.0-debug",
+		},
+		{
+			name:     "suffix strip",
+			rewriter: NewTagSuffixRewriter("-rc", ""),
+			input:    "example.com/app:1.0-rc",
+			expected: "example.com/app:1.0",
+		},
+		{
+			name:     "regexp capture",
+			rewriter: stripRC,
+			input:    "example.com/app:2.0-rc",
+			expected: "example.com/app:2.0",
+		},
+		{
+			name:     "digest is preserved",
+			rewriter: stripRC,
+			input:    "example.com/app:2.0-rc@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+			expected: "example.com/app:2.0@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+		},
+		{
+			name:     "non-matching prefix",
+			rewriter: NewTagPrefixRewriter("v", ""),
+			input:    "example.com/app:1.0",
+			expected: "example.com/app:1.0",
+		},
+		{
+			name:     "non-matching regexp",
+			rewriter: stripRC,
+			input:    "example.com/app:2.0-rc1",
+			expected: "example.com/app:2.0-rc1",
+		},
+		{
+			name:     "untagged",
+			rewriter: NewTagSuffixRewriter("", "-debug"),
+			input:    "example.com/app",
+			expected: "example.com/app",
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.name, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseNormalizedNamed(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rewritten, err := testcase.rewriter.Apply(ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rewritten.String() != testcase.expected {
+				t.Errorf("unexpected: got %q, expected %q", rewritten.String(), testcase.expected)
+			}
+		})
+	}
+}
+
+func TestTagRewriterInvalid(t *testing.T) {
+	t.Parallel()
+	if _, err := NewTagRewriter(`(`, ""); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+
+	ref, err := ParseNormalizedNamed("example.com/app:1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTagPrefixRewriter("", "-").Apply(ref); !errors.Is(err, ErrTagInvalidFormat) {
+		t.Errorf("expected %v, got %v", ErrTagInvalidFormat, err)
+	}
+}
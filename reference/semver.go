@@ -0,0 +1,138 @@
+package reference
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrNoSemverTag is returned by [LatestSemver] if none of the references has
+// a semantic version tag.
+var ErrNoSemverTag = errors.New("no reference has a semantic version tag")
+
+// semverRegexp matches semantic versions, as defined by https://semver.org,
+// with an optional "v" prefix and an optional patch version. Build metadata
+// is not matched, as "+" is not allowed in tags.
+var semverRegexp = regexp.MustCompile(`^v?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(?:\.(0|[1-9][0-9]*))?(?:-((?:0|[1-9][0-9]*|[0-9]*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9][0-9]*|[0-9]*[a-zA-Z-][0-9a-zA-Z-]*))*))?$`)
+
+// IsSemverTag reports whether the tag of ref is a semantic version, and
+// returns the version without the optional "v" prefix. For example, it
+// returns "1.2.3" for both "1.2.3" and "v1.2.3", and "1.2.3-rc.1" for
+// "1.2.3-rc.1".
+//
+// As images are commonly tagged with partial versions, a version without a
+// patch number (such as "1.25") is also accepted, and is ordered as if its
+// patch number was 0. A major version alone (such as "1") is not accepted,
+// as it cannot be told apart from other numeric tags, such as build numbers.
+func IsSemverTag(ref NamedTagged) (semver string, ok bool) {
+	return semverTag(ref.Tag())
+}
+
+// semverTag is the implementation of [IsSemverTag] for a tag string.
+func semverTag(tag string) (semver string, ok bool) {
+	if !semverRegexp.MatchString(tag) {
+		return "", false
+	}
+	return strings.TrimPrefix(tag, "v"), true
+}
+
+// LatestSemver returns the reference with the highest semantic version tag
+// (see [IsSemverTag]) among refs, ignoring references with other tags. All
+// references must refer to the same repository. If multiple tags have the
+// same precedence (such as "1.2.3" and "v1.2.3"), the first one is returned.
+// [ErrNoSemverTag] is returned if none of the tags is a semantic version.
+func LatestSemver(refs []NamedTagged) (NamedTagged, error) {
+	var (
+		latest       NamedTagged
+		latestSemver string
+	)
+	for i, ref := range refs {
+		if i > 0 && (Domain(ref) != Domain(refs[0]) || Path(ref) != Path(refs[0])) {
+			return nil, fmt.Errorf("references must refer to the same repository: %q and %q", refs[0].Name(), ref.Name())
+		}
+		semver, ok := IsSemverTag(ref)
+		if !ok {
+			continue
+		}
+		if latest == nil || compareSemver(semver, latestSemver) > 0 {
+			latest, latestSemver = ref, semver
+		}
+	}
+	if latest == nil {
+		return nil, ErrNoSemverTag
+	}
+	return latest, nil
+}
+
+// compareSemver compares two semantic versions, as matched by semverRegexp
+// without "v" prefix, following the precedence rules of https://semver.org.
+// A missing patch number is treated as 0.
+func compareSemver(a, b string) int {
+	ma, mb := semverRegexp.FindStringSubmatch(a), semverRegexp.FindStringSubmatch(b)
+	for i := 1; i <= 3; i++ {
+		if c := compareNumeric(ma[i], mb[i]); c != 0 {
+			return c
+		}
+	}
+
+	// A version without pre-release has a higher precedence than one with.
+	preA, preB := ma[4], mb[4]
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	idsA, idsB := strings.Split(preA, "."), strings.Split(preB, ".")
+	for i := 0; i < len(idsA) && i < len(idsB); i++ {
+		numA, numB := isNumeric(idsA[i]), isNumeric(idsB[i])
+		var c int
+		switch {
+		case numA && numB:
+			c = compareNumeric(idsA[i], idsB[i])
+		case numA:
+			c = -1
+		case numB:
+			c = 1
+		default:
+			c = strings.Compare(idsA[i], idsB[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	// A larger set of identifiers has a higher precedence.
+	switch {
+	case len(idsA) < len(idsB):
+		return -1
+	case len(idsA) > len(idsB):
+		return 1
+	}
+	return 0
+}
+
+// compareNumeric compares two decimal numbers without leading zeros, of any
+// length. An empty string is treated as 0.
+func compareNumeric(a, b string) int {
+	if a == "" {
+		a = "0"
+	}
+	if b == "" {
+		b = "0"
+	}
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// isNumeric reports whether s only consists of digits.
+func isNumeric(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
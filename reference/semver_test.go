@@ -0,0 +1,164 @@
+package reference
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIsSemverTag(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		tag    string
+		semver string
+	}{
+		{tag: "1.2.3", semver: "1.2.3"},
+		{tag: "v1.2.3", semver: "1.2.3"},
+		{tag: "0.0.0", semver: "0.0.0"},
+		{tag: "1.2.3-rc.1", semver: "1.2.3-rc.1"},
+		{tag: "v10.20.30-alpha", semver: "10.20.30-alpha"},
+		{tag: "1.2", semver: "1.2"},
+		{tag: "v1.25", semver: "1.25"},
+		{tag: "latest"},
+		{tag: "1"},
+		{tag: "v1"},
+		{tag: "1.2.3.4"},
+		{tag: "01.2.3"},
+		{tag: "1.2.3-"},
+		{tag: "1.2.3-alpine", semver: "1.2.3-alpine"},
+		{tag: "1.2.3_alpine"},
+		{tag: "V1.2.3"},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.tag, func(t *testing.T) {
+			t.Parallel()
+			named, err := WithName("example.com/app")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tagged, err := WithTag(named, testcase.tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			semver, ok := IsSemverTag(tagged)
+			if semver != testcase.semver || ok != (testcase.semver != "") {
+				t.Errorf("unexpected: got (%q, %v), expected %q", semver, ok, testcase.semver)
+			}
+		})
+	}
+}
+
+func TestLatestSemver(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name     string
+		refs     []string
+		expected string
+		err      error
+	}{
+		{
+			name:     "mixed",
+			refs:     []string{"example.com/app:latest", "example.com/app:1.2.3", "example.com/app:v1.10.0", "example.com/app:1.9.9", "example.com/app:nightly"},
+			expected: "example.com/app:v1.10.0",
+		},
+		{
+			name:     "prerelease",
+			refs:     []string{"example.com/app:2.0.0-rc.1", "example.com/app:1.9.9", "example.com/app:2.0.0-beta.11", "example.com/app:2.0.0-rc.2"},
+			expected: "example.com/app:2.0.0-rc.2",
+		},
+		{
+			name:     "release after prerelease",
+			refs:     []string{"example.com/app:2.0.0-rc.1", "example.com/app:2.0.0", "example.com/app:2.0.0-rc.2"},
+			expected: "example.com/app:2.0.0",
+		},
+		{
+			name:     "partial version",
+			refs:     []string{"example.com/app:1.25", "example.com/app:1.24.9", "example.com/app:1.25.0"},
+			expected: "example.com/app:1.25",
+		},
+		{
+			name:     "large numbers",
+			refs:     []string{"example.com/app:99999999999999999999.0.0", "example.com/app:100000000000000000000.0.0"},
+			expected: "example.com/app:100000000000000000000.0.0",
+		},
+		{
+			name: "no semver",
+			refs: []string{"example.com/app:latest", "example.com/app:1"},
+			err:  ErrNoSemverTag,
+		},
+		{
+			name: "empty",
+			err:  ErrNoSemverTag,
+		},
+		{
+			name: "multiple repositories",
+			refs: []string{"example.com/app:1.2.3", "example.com/other:1.2.4"},
+		},
+		{
+			name: "multiple domains",
+			refs: []string{"example.com/app:1.2.3", "example.org/app:1.2.4"},
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.name, func(t *testing.T) {
+			t.Parallel()
+			refs := make([]NamedTagged, 0, len(testcase.refs))
+			for _, s := range testcase.refs {
+				ref, err := Parse(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				refs = append(refs, ref.(NamedTagged))
+			}
+			latest, err := LatestSemver(refs)
+			if testcase.expected == "" {
+				if err == nil {
+					t.Fatalf("expected error, got %s", latest)
+				}
+				if testcase.err != nil && !errors.Is(err, testcase.err) {
+					t.Fatalf("unexpected error: got %v, expected %v", err, testcase.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if latest.String() != testcase.expected {
+				t.Errorf("unexpected reference: got %s, expected %s", latest, testcase.expected)
+			}
+		})
+	}
+}
+
+func TestCompareSemver(t *testing.T) {
+	t.Parallel()
+	// Ordered by increasing precedence, from https://semver.org.
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.1",
+		"1.1.1",
+		"2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			got := compareSemver(ordered[i], ordered[j])
+			expected := 0
+			if i < j {
+				expected = -1
+			} else if i > j {
+				expected = 1
+			}
+			if got != expected {
+				t.Errorf("compareSemver(%q, %q): got %d, expected %d", ordered[i], ordered[j], got, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+//go:build go1.21
+
+package reference
+
+import (
+	"log/slog"
+)
+
+// LogValue returns a [slog.Value] for ref, grouping the domain, path, tag,
+// and digest of ref as separate attributes. Components that are not present
+// in ref are omitted.
+func LogValue(ref Reference) slog.Value {
+	if ref == nil {
+		return slog.GroupValue()
+	}
+	c := componentsOf(ref)
+	var attrs []slog.Attr
+	for _, attr := range []slog.Attr{
+		slog.String("domain", c.domain),
+		slog.String("path", c.path),
+		slog.String("tag", c.tag),
+		slog.String("digest", c.digest.String()),
+	} {
+		if attr.Value.String() != "" {
+			attrs = append(attrs, attr)
+		}
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue implements [slog.LogValuer], so that a Field is logged as a group
+// of attributes (see [LogValue]). The reference is only rendered if the
+// record is emitted:
+//
+//	logger.Info("pulling image", "image", reference.AsField(ref))
+func (f Field) LogValue() slog.Value {
+	return LogValue(f.reference)
+}
@@ -0,0 +1,53 @@
+//go:build go1.21
+
+package reference
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestLogValue(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "nginx",
+			expected: "msg=pull image.domain=docker.io image.path=library/nginx\n",
+		},
+		{
+			input:    "example.com:5000/app:1.0@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			expected: "msg=pull image.domain=example.com:5000 image.path=app image.tag=1.0 image.digest=sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff\n",
+		},
+		{
+			input:    "sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			expected: "msg=pull image.digest=sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff\n",
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			ref, err := ParseAnyReference(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+						return slog.Attr{}
+					}
+					return a
+				},
+			}))
+			logger.Info("pull", "image", AsField(ref))
+			if buf.String() != testcase.expected {
+				t.Errorf("unexpected output:\ngot:      %q\nexpected: %q", buf.String(), testcase.expected)
+			}
+		})
+	}
+}
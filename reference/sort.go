@@ -18,6 +18,7 @@ package reference
 
 import (
 	"sort"
+	"strings"
 )
 
 // Sort sorts string references preferring higher information references.
@@ -30,6 +31,10 @@ import (
 //  4. [Named]                         (e.g., "docker.io/library/busybox")
 //  5. [Digested]                      (e.g., "docker.io@sha256:<digest>")
 //  6. Parse error
+//
+// References with the same precedence are ordered by their string
+// representation, and parse errors are ordered by their input, using a
+// byte-wise comparison that does not depend on the locale or platform.
 func Sort(references []string) []string {
 	var prefs []Reference
 	var bad []string
@@ -73,3 +78,95 @@ func refRank(ref Reference) uint8 {
 	}
 	return 5
 }
+
+// SortForDisplay sorts refs in place in the order used for catalog display:
+//
+//  1. official images on Docker Hub (e.g., "ubuntu")
+//  2. other images on Docker Hub (e.g., "dmcgowan/myapp")
+//  3. images on other registries, ordered by domain (e.g., "gcr.io/project/app")
+//
+// Within each tier, references are ordered by path, then by tag, then by
+// digest. As with [Compare], components are compared byte-wise.
+func SortForDisplay(refs []Named) {
+	type displayKey struct {
+		tier   uint8
+		domain string
+		path   string
+		tag    string
+		digest string
+		ref    Named
+	}
+	keys := make([]displayKey, 0, len(refs))
+	for _, ref := range refs {
+		c := componentsOf(ref)
+		familiar := familiarizeName(repository{domain: c.domain, path: c.path})
+		key := displayKey{tier: 3, domain: familiar.domain, path: c.path, tag: c.tag, digest: c.digest.String(), ref: ref}
+		if familiar.domain == "" {
+			key.tier = 2
+			if !strings.ContainsRune(familiar.path, '/') {
+				key.tier = 1
+			}
+		}
+		keys = append(keys, key)
+	}
+	sort.SliceStable(keys, func(a, b int) bool {
+		ka, kb := keys[a], keys[b]
+		switch {
+		case ka.tier != kb.tier:
+			return ka.tier < kb.tier
+		case ka.domain != kb.domain:
+			return ka.domain < kb.domain
+		case ka.path != kb.path:
+			return ka.path < kb.path
+		case ka.tag != kb.tag:
+			return ka.tag < kb.tag
+		default:
+			return ka.digest < kb.digest
+		}
+	})
+	for i, key := range keys {
+		refs[i] = key.ref
+	}
+}
+
+// SortTags sorts tags in place in the order used for listing the tags of a
+// repository:
+//
+//  1. semantic versions (see [IsSemverTag]), highest version first
+//  2. other valid tags (e.g., "latest")
+//  3. invalid tags
+//
+// Semantic versions with the same precedence (such as "1.2.3" and "v1.2.3"),
+// other valid tags, and invalid tags are ordered byte-wise.
+func SortTags(tags []string) {
+	type tagKey struct {
+		tier   uint8
+		semver string
+		tag    string
+	}
+	keys := make([]tagKey, 0, len(tags))
+	for _, tag := range tags {
+		key := tagKey{tier: 3, tag: tag}
+		if semver, ok := semverTag(tag); ok {
+			key.tier, key.semver = 1, semver
+		} else if anchoredTagRegexp.MatchString(tag) {
+			key.tier = 2
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		ka, kb := keys[a], keys[b]
+		if ka.tier != kb.tier {
+			return ka.tier < kb.tier
+		}
+		if ka.tier == 1 {
+			if c := compareSemver(ka.semver, kb.semver); c != 0 {
+				return c > 0
+			}
+		}
+		return ka.tag < kb.tag
+	})
+	for i, key := range keys {
+		tags[i] = key.tag
+	}
+}
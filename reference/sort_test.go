@@ -19,6 +19,7 @@ package reference
 import (
 	"io"
 	"math/rand"
+	"reflect"
 	"testing"
 
 	"github.com/opencontainers/go-digest"
@@ -82,3 +83,59 @@ func TestReferenceSorting(t *testing.T) {
 		}
 	}
 }
+
+func TestSortForDisplay(t *testing.T) {
+	t.Parallel()
+	input := []string{
+		"quay.io/org/app:1.0",
+		"dmcgowan/myapp:latest",
+		"gcr.io/project/app",
+		"ubuntu:22.04",
+		"busybox",
+		"ubuntu:20.04",
+		"docker.io/library/alpine:3.18",
+		"localhost:5000/app",
+		"dmcgowan/another",
+		"gcr.io/project/api:v2",
+	}
+	expected := []string{
+		// official images on Docker Hub
+		"docker.io/library/alpine:3.18",
+		"docker.io/library/busybox",
+		"docker.io/library/ubuntu:20.04",
+		"docker.io/library/ubuntu:22.04",
+		// other images on Docker Hub
+		"docker.io/dmcgowan/another",
+		"docker.io/dmcgowan/myapp:latest",
+		// other registries, by domain
+		"gcr.io/project/api:v2",
+		"gcr.io/project/app",
+		"localhost:5000/app",
+		"quay.io/org/app:1.0",
+	}
+
+	refs := make([]Named, 0, len(input))
+	for _, s := range input {
+		ref, err := ParseNormalizedNamed(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs = append(refs, ref)
+	}
+	SortForDisplay(refs)
+	for i, ref := range refs {
+		if ref.String() != expected[i] {
+			t.Errorf("wrong value at %d, got %q, expected %q", i, ref.String(), expected[i])
+		}
+	}
+}
+
+func TestSortTags(t *testing.T) {
+	t.Parallel()
+	tags := []string{"alpha", "1.9", "latest", "1.10", "v1.10.1", "1.10.0-rc.1", "-invalid", "1.2.3", "v1.2.3", "2.0.0-alpine", "1"}
+	expected := []string{"2.0.0-alpine", "v1.10.1", "1.10", "1.10.0-rc.1", "1.9", "1.2.3", "v1.2.3", "1", "alpha", "latest", "-invalid"}
+	SortTags(tags)
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("unexpected order:\ngot      %q\nexpected %q", tags, expected)
+	}
+}
@@ -0,0 +1,53 @@
+package reference
+
+// Span is the byte range [Start, End) of a component in a reference string.
+// A span with Start equal to End is empty, and indicates that the component
+// is not present.
+type Span struct {
+	Start, End int
+}
+
+// IsEmpty reports whether the component of the span is not present.
+func (s Span) IsEmpty() bool {
+	return s.Start == s.End
+}
+
+// Spans holds the byte ranges of the components of a reference string, as
+// returned by [ComponentSpans]. The ranges do not include the separators
+// between the components ("/", ":", and "@").
+type Spans struct {
+	Domain Span
+	Path   Span
+	Tag    Span
+	Digest Span
+}
+
+// ComponentSpans returns the byte ranges of the domain, path, tag, and
+// digest in s, for example, for syntax highlighting. The ranges refer to s
+// as-is, before normalization: for "nginx:1.25", the domain is empty, the
+// path is [0, 5), and the tag is [6, 10). As for [ParseNormalizedNamed], the
+// first component of s is only a domain if it contains a "." or ":", if it is
+// "localhost", or if it contains uppercase characters; otherwise it is part
+// of the path.
+//
+// An error is returned if s is not a valid reference according to [Parse].
+func ComponentSpans(s string) (Spans, error) {
+	if _, err := Parse(s); err != nil {
+		return Spans{}, err
+	}
+	var spans Spans
+	m := ReferenceRegexp.FindStringSubmatchIndex(s)
+	name := s[m[2]:m[3]]
+	spans.Path = Span{Start: m[2], End: m[3]}
+	if n := anchoredNameRegexp.FindStringSubmatchIndex(name); n[2] != -1 && isDomainComponent(name[n[2]:n[3]]) {
+		spans.Domain = Span{Start: m[2] + n[2], End: m[2] + n[3]}
+		spans.Path = Span{Start: m[2] + n[4], End: m[2] + n[5]}
+	}
+	if m[4] != -1 {
+		spans.Tag = Span{Start: m[4], End: m[5]}
+	}
+	if m[6] != -1 {
+		spans.Digest = Span{Start: m[6], End: m[7]}
+	}
+	return spans, nil
+}
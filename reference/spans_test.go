@@ -0,0 +1,63 @@
+package reference
+
+import (
+	"testing"
+)
+
+func TestComponentSpans(t *testing.T) {
+	t.Parallel()
+	const dgst = "sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa"
+	testcases := []struct {
+		input    string
+		expected Spans
+	}{
+		{
+			input: "docker.io/library/nginx:1.25@" + dgst,
+			expected: Spans{
+				Domain: Span{Start: 0, End: 9},
+				Path:   Span{Start: 10, End: 23},
+				Tag:    Span{Start: 24, End: 28},
+				Digest: Span{Start: 29, End: 29 + len(dgst)},
+			},
+		},
+		{
+			input:    "nginx:1.25",
+			expected: Spans{Path: Span{Start: 0, End: 5}, Tag: Span{Start: 6, End: 10}},
+		},
+		{
+			input:    "user/app",
+			expected: Spans{Path: Span{Start: 0, End: 8}},
+		},
+		{
+			input:    "localhost:5000/app@" + dgst,
+			expected: Spans{Domain: Span{Start: 0, End: 14}, Path: Span{Start: 15, End: 18}, Digest: Span{Start: 19, End: 19 + len(dgst)}},
+		},
+		{
+			input:    "[::1]:5000/team/app:v1",
+			expected: Spans{Domain: Span{Start: 0, End: 10}, Path: Span{Start: 11, End: 19}, Tag: Span{Start: 20, End: 22}},
+		},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.input, func(t *testing.T) {
+			t.Parallel()
+			spans, err := ComponentSpans(testcase.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if spans != testcase.expected {
+				t.Errorf("unexpected spans: got %+v, expected %+v", spans, testcase.expected)
+			}
+			for _, span := range []Span{spans.Domain, spans.Path, spans.Tag, spans.Digest} {
+				if !span.IsEmpty() && (span.Start < 0 || span.End > len(testcase.input) || span.Start > span.End) {
+					t.Errorf("span %+v out of range", span)
+				}
+			}
+		})
+	}
+	for _, input := range []string{"", "nginx:", "Nginx", "example.com/app@sha256:abc"} {
+		if spans, err := ComponentSpans(input); err == nil {
+			t.Errorf("%q: expected error, got %+v", input, spans)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package reference
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RewriteStream copies a Dockerfile from r to w, replacing the image
+// reference of each FROM instruction with the reference returned by rewrite.
+// Only the image token is replaced, using the full string of the rewritten
+// reference; flags such as "--platform", "AS <stage>" aliases, comments, and
+// all other lines are copied verbatim.
+//
+// The reserved "scratch" image, image tokens that are not valid references
+// (for example, "${BASE_IMAGE}"), and tokens that refer to a build stage
+// defined earlier in the stream are not passed to rewrite. FROM instructions
+// are expected to be on a single line; line continuations are not supported.
+func RewriteStream(r io.Reader, w io.Writer, rewrite func(Named) (Named, error)) error {
+	br := bufio.NewReader(r)
+	stages := make(map[string]struct{})
+	for lineNum := 1; ; lineNum++ {
+		line, readErr := br.ReadString('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return readErr
+		}
+		if line != "" {
+			rewritten, err := rewriteFromLine(line, stages, rewrite)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			if _, err := io.WriteString(w, rewritten); err != nil {
+				return err
+			}
+		}
+		if readErr != nil {
+			return nil
+		}
+	}
+}
+
+// rewriteFromLine rewrites the image reference in line if it is a FROM
+// instruction, and records the stage alias it defines (if any) in stages.
+func rewriteFromLine(line string, stages map[string]struct{}, rewrite func(Named) (Named, error)) (string, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "FROM") {
+		return line, nil
+	}
+
+	// Find the image token, skipping flags.
+	image := ""
+	for _, field := range fields[1:] {
+		if !strings.HasPrefix(field, "--") {
+			image = field
+			break
+		}
+	}
+	if image == "" {
+		return line, nil
+	}
+	_, isStage := stages[strings.ToLower(image)]
+	if n := len(fields); n >= 4 && strings.EqualFold(fields[n-2], "AS") {
+		stages[strings.ToLower(fields[n-1])] = struct{}{}
+	}
+	if isStage || IsScratch(image) {
+		return line, nil
+	}
+
+	named, err := parseNormalized(image)
+	if err != nil {
+		return line, nil
+	}
+	rewritten, err := rewrite(named)
+	if err != nil {
+		return "", fmt.Errorf("rewriting %q: %w", image, err)
+	}
+
+	// Locate the image token in the original line to preserve whitespace.
+	offset := len(line) - len(strings.TrimLeft(line, " \t"))
+	offset += len(fields[0])
+	for _, field := range fields[1:] {
+		i := strings.Index(line[offset:], field)
+		if field == image {
+			offset += i
+			break
+		}
+		offset += i + len(field)
+	}
+	return line[:offset] + rewritten.String() + line[offset+len(image):], nil
+}
+
+// LineError describes a line of input that could not be parsed as a
+// reference.
+type LineError struct {
+	// Line is the line number, starting at 1.
+	Line int
+
+	// Text is the raw text of the line.
+	Text string
+
+	// Err is the error returned by the parser.
+	Err error
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %q: %v", e.Line, e.Text, e.Err)
+}
+
+// Unwrap returns the underlying parse error.
+func (e LineError) Unwrap() error {
+	return e.Err
+}
+
+// PartitionLines reads a list of references from r, one per line, and
+// parses each line using [ParseNormalizedNamed]. Leading and trailing
+// whitespace is ignored, and blank lines and lines starting with "#" are
+// skipped. It returns the references that were parsed successfully, and a
+// [LineError] for each line that could not be parsed. An error reading from r
+// is reported as a LineError for the line at which reading stopped.
+func PartitionLines(r io.Reader) (valid []Named, invalid []LineError) {
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		text := scanner.Text()
+		line := strings.TrimSpace(text)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		named, err := parseNormalized(line)
+		if err != nil {
+			invalid = append(invalid, LineError{Line: lineNum, Text: text, Err: err})
+			continue
+		}
+		valid = append(valid, named)
+	}
+	if err := scanner.Err(); err != nil {
+		invalid = append(invalid, LineError{Line: lineNum + 1, Err: err})
+	}
+	return valid, invalid
+}
+
+// ParseLineWithComment parses a line of a pin file, which holds a reference
+// optionally followed by a comment, such as "nginx:1.25  # pinned 2024-01".
+// A comment starts with a "#" at the start of the line or preceded by
+// whitespace, and extends to the end of the line. The reference part is
+// parsed using [ParseNormalizedNamed], ignoring leading and trailing
+// whitespace, and the comment is returned without the "#" and surrounding
+// whitespace. A "#" within the reference (as in "nginx#1.25") does not start
+// a comment, and is rejected by the parser.
+func ParseLineWithComment(line string) (Named, string, error) {
+	text, comment := line, ""
+	for i := 0; i < len(line); i++ {
+		if line[i] == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+			text, comment = line[:i], strings.TrimSpace(line[i+1:])
+			break
+		}
+	}
+	named, err := parseNormalized(strings.TrimSpace(text))
+	if err != nil {
+		return nil, "", err
+	}
+	return named, comment, nil
+}
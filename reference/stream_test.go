@@ -0,0 +1,201 @@
+package reference
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func TestRewriteStream(t *testing.T) {
+	t.Parallel()
+	const dgst = digest.Digest("sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa")
+	pin := func(named Named) (Named, error) {
+		return WithDigest(TrimNamed(named), dgst)
+	}
+
+	input := strings.Join([]string{
+		"# syntax=docker/dockerfile:1",
+		"# FROM commented:out",
+		"FROM --platform=$BUILDPLATFORM golang:1.21 AS build",
+		"RUN go build -o /out/app .",
+		"",
+		"from  gcr.io/distroless/static   as  base\r",
+		"FROM build AS test",
+		"FROM ${BASE_IMAGE}",
+		"FROM scratch",
+		"COPY --from=build /out/app /app",
+		"FROM base",
+	}, "\n")
+	expected := strings.Join([]string{
+		"# syntax=docker/dockerfile:1",
+		"# FROM commented:out",
+		"FROM --platform=$BUILDPLATFORM docker.io/library/golang@" + dgst.String() + " AS build",
+		"RUN go build -o /out/app .",
+		"",
+		"from  gcr.io/distroless/static@" + dgst.String() + "   as  base\r",
+		"FROM build AS test",
+		"FROM ${BASE_IMAGE}",
+		"FROM scratch",
+		"COPY --from=build /out/app /app",
+		"FROM base",
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := RewriteStream(strings.NewReader(input), &out, pin); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
+
+func TestRewriteStreamScratch(t *testing.T) {
+	t.Parallel()
+	input := strings.Join([]string{
+		"FROM scratch",
+		"FROM --platform=linux/amd64 Scratch AS base",
+		"from SCRATCH\r",
+		"FROM library/scratch",
+	}, "\n")
+	var rewritten []string
+	var out bytes.Buffer
+	err := RewriteStream(strings.NewReader(input), &out, func(named Named) (Named, error) {
+		rewritten = append(rewritten, named.String())
+		return named, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Replace(input, "library/scratch", "docker.io/library/scratch", 1)
+	if out.String() != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+	// Only the explicit repository is passed to rewrite, as "scratch" is not
+	// an image that can be pulled.
+	if len(rewritten) != 1 || rewritten[0] != "docker.io/library/scratch" {
+		t.Errorf("unexpected references passed to rewrite: %q", rewritten)
+	}
+}
+
+func TestRewriteStreamError(t *testing.T) {
+	t.Parallel()
+	errResolve := errors.New("cannot resolve")
+	input := "FROM alpine\nFROM nginx:1.25\n"
+	err := RewriteStream(strings.NewReader(input), &bytes.Buffer{}, func(named Named) (Named, error) {
+		if FamiliarName(named) == "nginx" {
+			return nil, errResolve
+		}
+		return named, nil
+	})
+	if !errors.Is(err, errResolve) {
+		t.Fatalf("expected %v, got %v", errResolve, err)
+	}
+	if !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected error to include line number, got %q", err.Error())
+	}
+}
+
+func TestPartitionLines(t *testing.T) {
+	t.Parallel()
+	input := strings.Join([]string{
+		"# images to migrate",
+		"nginx:1.25",
+		"",
+		"  example.com/team/app  ",
+		"docker/Docker",
+		"\t# indented comment",
+		"docker///docker",
+		"gcr.io/project/app@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+	}, "\n")
+
+	valid, invalid := PartitionLines(strings.NewReader(input))
+
+	expectedValid := []string{
+		"docker.io/library/nginx:1.25",
+		"example.com/team/app",
+		"gcr.io/project/app@sha256:86e0e091d0da6bde2456dbb48306f3956bbeb2eae1b5b9a43045843f69fe4aaa",
+	}
+	if len(valid) != len(expectedValid) {
+		t.Fatalf("unexpected valid references: got %v, expected %v", valid, expectedValid)
+	}
+	for i, named := range valid {
+		if named.String() != expectedValid[i] {
+			t.Errorf("unexpected valid reference %d: got %q, expected %q", i, named.String(), expectedValid[i])
+		}
+	}
+
+	expectedInvalid := []struct {
+		line int
+		text string
+	}{
+		{line: 5, text: "docker/Docker"},
+		{line: 7, text: "docker///docker"},
+	}
+	if len(invalid) != len(expectedInvalid) {
+		t.Fatalf("unexpected invalid lines: got %v, expected %v", invalid, expectedInvalid)
+	}
+	for i, lineErr := range invalid {
+		if lineErr.Line != expectedInvalid[i].line || lineErr.Text != expectedInvalid[i].text {
+			t.Errorf("unexpected invalid line %d: got (%d, %q), expected (%d, %q)", i, lineErr.Line, lineErr.Text, expectedInvalid[i].line, expectedInvalid[i].text)
+		}
+		if lineErr.Err == nil {
+			t.Errorf("expected parse error for line %d", lineErr.Line)
+		}
+	}
+	if !errors.Is(invalid[1], ErrReferenceInvalidFormat) {
+		t.Errorf("expected %v to match %v", invalid[1], ErrReferenceInvalidFormat)
+	}
+}
+
+func TestParseLineWithComment(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		line     string
+		expected string
+		comment  string
+		err      bool
+	}{
+		{line: "nginx:1.25", expected: "docker.io/library/nginx:1.25"},
+		{line: "  nginx:1.25  ", expected: "docker.io/library/nginx:1.25"},
+		{line: "nginx:1.25  # pinned 2024-01", expected: "docker.io/library/nginx:1.25", comment: "pinned 2024-01"},
+		{line: "example.com/app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff\t#digest", expected: "example.com/app@sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", comment: "digest"},
+		{line: "nginx #", expected: "docker.io/library/nginx"},
+		{line: "nginx # a # b", expected: "docker.io/library/nginx", comment: "a # b"},
+		{line: "nginx#1.25", err: true},
+		{line: "nginx#1.25 # comment", err: true},
+		{line: "# comment only", err: true},
+		{line: "", err: true},
+	}
+	for _, testcase := range testcases {
+		testcase := testcase
+		t.Run(testcase.line, func(t *testing.T) {
+			t.Parallel()
+			named, comment, err := ParseLineWithComment(testcase.line)
+			if testcase.err {
+				if err == nil {
+					t.Fatalf("expected error, got %v %q", named, comment)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if named.String() != testcase.expected {
+				t.Errorf("unexpected reference: got %q, expected %q", named.String(), testcase.expected)
+			}
+			if comment != testcase.comment {
+				t.Errorf("unexpected comment: got %q, expected %q", comment, testcase.comment)
+			}
+		})
+	}
+
+	// The strict parser still rejects "#" in a reference.
+	for _, input := range []string{"nginx#1.25", "nginx:1.25#comment"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("%q: expected error from Parse", input)
+		}
+	}
+}